@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// InstanceProfile is a named shape of a saved instance, applied with
+// `connect --profile NAME` while the instance is stopped. The accelerators
+// listed replace the attached ones; an empty list detaches them all.
+type InstanceProfile struct {
+	MachineType  string        `json:"machine_type,omitempty"`
+	Accelerators []Accelerator `json:"accelerators,omitempty"`
+}
+
+// Accelerator is a GPU model and how many of it to attach.
+type Accelerator struct {
+	Type  string `json:"type"` // e.g. nvidia-tesla-t4
+	Count int    `json:"count"`
+}
+
+// acceleratorTypePattern matches GPU model names such as nvidia-l4.
+var acceleratorTypePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func validateProfile(name string, profile InstanceProfile) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("profiles: a profile has an empty name")
+	}
+	if profile.MachineType != "" {
+		if err := validateMachineType(profile.MachineType); err != nil {
+			return fmt.Errorf("profile '%s': %w", name, err)
+		}
+	}
+	for _, acc := range profile.Accelerators {
+		if !acceleratorTypePattern.MatchString(acc.Type) || acc.Count < 1 {
+			return fmt.Errorf("profile '%s': invalid accelerator %s (expected a type like nvidia-tesla-t4 and a count of 1 or more)", name, formatAccelerators([]Accelerator{acc}))
+		}
+	}
+	return nil
+}
+
+// profileNames returns the names of an instance's profiles, sorted.
+func profileNames(inst Instance) []string {
+	var names []string
+	for name := range inst.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatAccelerators renders accelerators as "2 x nvidia-tesla-t4", or
+// "no GPUs".
+func formatAccelerators(accs []Accelerator) string {
+	if len(accs) == 0 {
+		return "no GPUs"
+	}
+	var parts []string
+	for _, acc := range accs {
+		parts = append(parts, fmt.Sprintf("%d x %s", acc.Count, acc.Type))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatProfile renders a profile as "n1-standard-8 + 2 x nvidia-tesla-t4",
+// leaving out the machine type if the profile keeps the current one.
+func formatProfile(profile InstanceProfile) string {
+	if profile.MachineType == "" {
+		return formatAccelerators(profile.Accelerators)
+	}
+	return profile.MachineType + " + " + formatAccelerators(profile.Accelerators)
+}
+
+// sameAccelerators compares accelerator lists regardless of order.
+func sameAccelerators(a, b []Accelerator) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := map[string]int{}
+	for _, acc := range a {
+		counts[acc.Type] += acc.Count
+	}
+	for _, acc := range b {
+		counts[acc.Type] -= acc.Count
+	}
+	for _, n := range counts {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// attachedAccelerators returns the accelerators of a described instance.
+func (d instanceDetails) attachedAccelerators() []Accelerator {
+	var accs []Accelerator
+	for _, acc := range d.GuestAccelerators {
+		accs = append(accs, Accelerator{Type: lastPathSegment(acc.AcceleratorType), Count: acc.AcceleratorCount})
+	}
+	return accs
+}
+
+// ─── Compute API ─────────────────────────────────────────────────────────────
+
+// gcloud has no command for changing the accelerators of an existing
+// instance, so they are set through the Compute Engine API's
+// setMachineResources method, authenticated with gcloud's access token.
+
+const computeAPIBase = "https://compute.googleapis.com/compute/v1"
+
+// setAccelerators replaces the accelerators of a stopped instance. GPUs need
+// the instance to terminate on host maintenance, which is set first.
+func setAccelerators(ctx context.Context, inst Instance, details instanceDetails, accs []Accelerator) error {
+	if len(accs) > 0 && details.Scheduling.OnHostMaintenance != "TERMINATE" {
+		ctx, cancel := context.WithTimeout(ctx, gcloudQueryTimeout)
+		defer cancel()
+		if err := runGcloudCommand(ctx, "compute", "instances", "set-scheduling", inst.Name,
+			"--project", inst.Project,
+			"--zone", inst.Zone,
+			"--maintenance-policy", "TERMINATE"); err != nil {
+			return err
+		}
+	}
+
+	type guestAccelerator struct {
+		AcceleratorType  string `json:"acceleratorType"`
+		AcceleratorCount int    `json:"acceleratorCount"`
+	}
+	body := struct {
+		GuestAccelerators []guestAccelerator `json:"guestAccelerators"`
+	}{GuestAccelerators: []guestAccelerator{}}
+	for _, acc := range accs {
+		body.GuestAccelerators = append(body.GuestAccelerators, guestAccelerator{
+			AcceleratorType:  fmt.Sprintf("projects/%s/zones/%s/acceleratorTypes/%s", inst.Project, inst.Zone, acc.Type),
+			AcceleratorCount: acc.Count,
+		})
+	}
+	url := fmt.Sprintf("%s/projects/%s/zones/%s/instances/%s/setMachineResources", computeAPIBase, inst.Project, inst.Zone, inst.Name)
+	return callComputeOperation(ctx, inst, url, body)
+}
+
+// computeOperation is the subset of a Compute Engine operation that is read.
+type computeOperation struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  *struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"error"`
+}
+
+// callComputeOperation POSTs body to a Compute Engine method that returns a
+// zonal operation and waits for the operation to finish.
+func callComputeOperation(ctx context.Context, inst Instance, url string, body any) error {
+	data, _ := json.Marshal(body)
+	call := "POST " + url + " " + string(data)
+	if options.dryRun {
+		logger.Info("dry run, not calling API", "call", call)
+		fmt.Printf("  [dry-run] would call: %s\n", call)
+		return nil
+	}
+
+	token, err := runGcloudValueCommand(ctx, "auth", "print-access-token")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, gcloudStartTimeout)
+	defer cancel()
+
+	var op computeOperation
+	if err := computeRequest(ctx, token, call, url, data, &op); err != nil {
+		return err
+	}
+	for op.Status != "DONE" {
+		if op.Name == "" {
+			return newGcloudError(call, "", fmt.Errorf("API returned no operation to wait for (status %q)", op.Status))
+		}
+		// The wait method returns once the operation is done or after about
+		// two minutes, whichever comes first.
+		waitURL := fmt.Sprintf("%s/projects/%s/zones/%s/operations/%s/wait", computeAPIBase, inst.Project, inst.Zone, op.Name)
+		var next computeOperation
+		if err := computeRequest(ctx, token, call, waitURL, nil, &next); err != nil {
+			return err
+		}
+		if next.Name == "" {
+			next.Name = op.Name
+		}
+		op = next
+	}
+	if op.Error != nil {
+		var lines []string
+		for _, e := range op.Error.Errors {
+			lines = append(lines, e.Code+": "+e.Message)
+		}
+		gerr := newGcloudError(call, strings.Join(lines, "\n"), fmt.Errorf("operation %s failed", op.Name))
+		logger.Warn("operation failed", "call", call, "kind", gerr.Kind, "errors", lines)
+		return gerr
+	}
+	logger.Debug("operation done", "call", call, "operation", op.Name)
+	return nil
+}
+
+// computeRequest POSTs data to url and decodes the JSON response into out.
+// Failures carry the API's error body so they are classified like gcloud's.
+func computeRequest(ctx context.Context, token, call, url string, data []byte, out any) error {
+	logger.Info("calling API", "url", url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := (&http.Client{Timeout: 3 * time.Minute}).Do(req)
+	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return &gcloudError{Command: call, Err: ctxErr}
+		}
+		return newGcloudError(call, "", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		gerr := newGcloudError(call, string(respBody), fmt.Errorf("HTTP %d", resp.StatusCode))
+		logger.Warn("API call failed", "url", url, "status", resp.StatusCode, "kind", gerr.Kind, "body", string(respBody))
+		return gerr
+	}
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// validateAlias checks that alias can be used for a new saved instance.
+func validateAlias(config *Config, alias string) error {
+	return validateAliasExcept(config, alias, -1)
+}
+
+// validateAliasExcept is validateAlias ignoring the saved instance at index
+// skip, so an entry can be renamed to a different spelling of itself.
+func validateAliasExcept(config *Config, alias string, skip int) error {
+	if alias == "" {
+		return fmt.Errorf("alias must not be empty")
+	}
+	if strings.ContainsAny(alias, " \t") || strings.HasPrefix(alias, "-") {
+		return fmt.Errorf("alias '%s' must not contain spaces or start with '-'", alias)
+	}
+	// Aliases must not collide with commands, or `gcp-ssh <alias>` would run
+	// the command instead.
+	for _, cmd := range commands {
+		if strings.EqualFold(alias, cmd.name) {
+			return fmt.Errorf("alias '%s' collides with the '%s' command", alias, cmd.name)
+		}
+	}
+	for i, inst := range config.Instances {
+		if i != skip && strings.EqualFold(inst.Alias, alias) {
+			return fmt.Errorf("alias '%s' already exists", inst.Alias)
+		}
+	}
+	return nil
+}
+
+// lookupAlias returns the index of the saved instance whose alias matches
+// exactly, falling back to a case-insensitive match.
+func lookupAlias(config *Config, alias string) (int, bool) {
+	for i, inst := range config.Instances {
+		if inst.Alias == alias {
+			return i, true
+		}
+	}
+	for i, inst := range config.Instances {
+		if strings.EqualFold(inst.Alias, alias) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// resolveAlias finds the saved instance for an alias, matching exactly, then
+// case-insensitively, then by unique prefix. Ambiguous prefixes open the
+// fuzzy picker when running in a terminal; unknown aliases print suggestions.
+func resolveAlias(config *Config, alias string) (Instance, bool) {
+	if i, ok := lookupAlias(config, alias); ok {
+		return config.Instances[i], true
+	}
+
+	var candidates []Instance
+	for _, inst := range sortedInstances(config) {
+		if alias != "" && strings.HasPrefix(strings.ToLower(inst.Alias), strings.ToLower(alias)) {
+			candidates = append(candidates, inst)
+		}
+	}
+
+	switch {
+	case len(candidates) == 1:
+		fmt.Printf("  ℹ Using '%s'.\n", candidates[0].Alias)
+		return candidates[0], true
+	case len(candidates) > 1 && interactiveTerminal():
+		return pickInstance(candidates, alias)
+	case len(candidates) > 1:
+		var names []string
+		for _, inst := range candidates {
+			names = append(names, inst.Alias)
+		}
+		fmt.Printf("  ✗ Alias '%s' is ambiguous: %s\n", alias, strings.Join(names, ", "))
+		return Instance{}, false
+	}
+	printAliasNotFound(config, alias)
+	return Instance{}, false
+}
+
+// printAliasNotFound reports an unknown alias with "did you mean"
+// suggestions.
+func printAliasNotFound(config *Config, alias string) {
+	if suggestions := suggestAliases(config, alias); len(suggestions) > 0 {
+		fmt.Printf("  ✗ Alias '%s' not found. Did you mean: %s?\n", alias, strings.Join(suggestions, ", "))
+		return
+	}
+	fmt.Printf("  ✗ Alias '%s' not found. Use 'list' to see saved instances.\n", alias)
+}
+
+// maxSuggestions is the number of "did you mean" candidates shown.
+const maxSuggestions = 3
+
+// suggestAliases returns the saved aliases closest to alias by edit distance,
+// ignoring ones that are too different to be a likely typo.
+func suggestAliases(config *Config, alias string) []string {
+	type candidate struct {
+		alias    string
+		distance int
+	}
+	limit := max(2, len([]rune(alias))/3)
+	var candidates []candidate
+	for _, inst := range config.Instances {
+		if d := editDistance(strings.ToLower(alias), strings.ToLower(inst.Alias)); d <= limit {
+			candidates = append(candidates, candidate{inst.Alias, d})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+
+	var result []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		result = append(result, candidates[i].alias)
+	}
+	return result
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func aliasConfig(aliases ...string) *Config {
+	config := &Config{}
+	for _, alias := range aliases {
+		config.Instances = append(config.Instances, Instance{Alias: alias, Project: "p", Zone: "us-central1-a", Name: alias})
+	}
+	return config
+}
+
+func TestResolveAlias(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	config := aliasConfig("dev", "Dev-GPU", "devbox", "prod", "staging")
+
+	resolves := func(query, want string) {
+		t.Helper()
+		inst, ok := resolveAlias(config, query)
+		if !ok || inst.Alias != want {
+			t.Errorf("resolveAlias(%q) = %q, %v; want %q", query, inst.Alias, ok, want)
+		}
+	}
+	fails := func(query string) {
+		t.Helper()
+		if inst, ok := resolveAlias(config, query); ok {
+			t.Errorf("resolveAlias(%q) = %q, want no match", query, inst.Alias)
+		}
+	}
+
+	t.Run("exact beats prefix", func(t *testing.T) { resolves("dev", "dev") })
+	t.Run("case-insensitive", func(t *testing.T) {
+		resolves("DEV", "dev")
+		resolves("dev-gpu", "Dev-GPU")
+		resolves("PROD", "prod")
+	})
+	t.Run("unique prefix", func(t *testing.T) {
+		resolves("stag", "staging")
+		resolves("Pr", "prod")
+		resolves("dev-", "Dev-GPU")
+		resolves("devb", "devbox")
+	})
+	t.Run("ambiguous prefix", func(t *testing.T) {
+		// stdin is not a terminal under go test, so no picker opens.
+		fails("d")
+		fails("De")
+	})
+	t.Run("unknown", func(t *testing.T) {
+		fails("qa")
+		fails("")
+		fails("devboxx")
+	})
+}
+
+func TestEditDistance(t *testing.T) {
+	cases := map[[2]string]int{
+		{"", ""}:               0,
+		{"", "dev"}:            3,
+		{"dev", "dev"}:         0,
+		{"dev", "dex"}:         1,
+		{"dev", "devbox"}:      3,
+		{"staging", "stagign"}: 2,
+		{"kitten", "sitting"}:  3,
+		{"café", "cafe"}:       1,
+	}
+	for pair, want := range cases {
+		a, b := pair[0], pair[1]
+		if got := editDistance(a, b); got != want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", a, b, got, want)
+		}
+		if got := editDistance(b, a); got != want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d (not symmetric)", b, a, got, want)
+		}
+	}
+}
+
+func TestSuggestAliases(t *testing.T) {
+	config := aliasConfig("staging", "stage", "prod", "production", "dev")
+
+	if got, want := suggestAliases(config, "stagin"), []string{"staging", "stage"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("suggestAliases(\"stagin\") = %q, want %q", got, want)
+	}
+	if got, want := suggestAliases(config, "PROF"), []string{"prod"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("suggestAliases(\"PROF\") = %q, want %q", got, want)
+	}
+	if got := suggestAliases(config, "qa"); got != nil {
+		t.Errorf("suggestAliases(\"qa\") = %q, want none", got)
+	}
+
+	many := aliasConfig("ab1", "ab2", "ab3", "ab4")
+	if got := suggestAliases(many, "ab"); len(got) != maxSuggestions {
+		t.Errorf("suggestAliases returned %d suggestions, want %d", len(got), maxSuggestions)
+	}
+}
+
+func TestValidateAlias(t *testing.T) {
+	config := aliasConfig("dev")
+	for _, alias := range []string{"prod", "dev-2", "Dev2"} {
+		if err := validateAlias(config, alias); err != nil {
+			t.Errorf("validateAlias(%q) = %v, want nil", alias, err)
+		}
+	}
+	for _, alias := range []string{"", "my dev", "-x", "DEV", "list", "Connect"} {
+		if err := validateAlias(config, alias); err == nil {
+			t.Errorf("validateAlias(%q) = nil, want an error", alias)
+		}
+	}
+}
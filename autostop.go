@@ -0,0 +1,404 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// autoStopWarning is how long the cancellable countdown runs after a terminal
+// session before auto-stop takes effect.
+const autoStopWarning = 30 * time.Second
+
+// parseAutoStop interprets an auto_stop policy. It returns whether auto-stop
+// is enabled and how long to wait without sessions before stopping.
+// Accepted values are "", "never", "immediately", a number of minutes or a
+// Go duration such as "45m".
+func parseAutoStop(policy string) (time.Duration, bool, error) {
+	switch policy {
+	case "", "never":
+		return 0, false, nil
+	case "immediately":
+		return 0, true, nil
+	}
+	if minutes, err := strconv.Atoi(policy); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute, true, nil
+	}
+	if d, err := time.ParseDuration(policy); err == nil && d > 0 {
+		return d, true, nil
+	}
+	return 0, false, fmt.Errorf("invalid auto-stop policy '%s' (use never, immediately, or minutes like 30)", policy)
+}
+
+// autoStopAfterSession applies the instance's auto_stop policy once a
+// terminal session has ended: immediately, or through a background watcher
+// once the instance has been idle for the configured window. Either way a
+// countdown runs first, and Ctrl-C keeps the instance running.
+func autoStopAfterSession(inst Instance, restore *instanceShape) {
+	delay, enabled, err := parseAutoStop(inst.AutoStop)
+	if err != nil {
+		fmt.Printf("  ⚠ Ignoring auto-stop: %v\n", err)
+		return
+	}
+	if !enabled {
+		return
+	}
+	if !options.dryRun {
+		action := fmt.Sprintf("Stopping '%s'", inst.Name)
+		if delay > 0 {
+			action = fmt.Sprintf("Scheduling the auto-stop of '%s' after %s idle", inst.Name, delay)
+		}
+		ctx, stop := interruptContext()
+		proceed := autoStopCountdown(ctx, inst, action, autoStopWarning)
+		stop()
+		if !proceed {
+			return
+		}
+	}
+	if delay > 0 {
+		startAutoStopWatch(inst, delay, restore)
+		return
+	}
+	if dryRunSkip("stop '%s' (auto_stop=%s) once the session ends", inst.Name, inst.AutoStop) {
+		return
+	}
+	if n := otherActiveSessions(inst); n > 0 {
+		fmt.Printf("  ℹ %d other gcp-ssh session(s) still use '%s'; not stopping it.\n", n, inst.Name)
+		return
+	}
+	if stopInstance(context.Background(), inst) {
+		restoreShape(context.Background(), inst, restore)
+	}
+}
+
+// autoStopCountdown counts down warning before an auto-stop takes effect,
+// describing it with action. It returns false if ctx is cancelled, which
+// also cancels a pending watcher for the instance, or if another gcp-ssh
+// session starts using the instance meanwhile.
+func autoStopCountdown(ctx context.Context, inst Instance, action string, warning time.Duration) bool {
+	deadline := time.Now().Add(warning)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	// newline ends the countdown line once one has been printed.
+	newline := ""
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			fmt.Print(newline)
+			return true
+		}
+		if otherActiveSessions(inst) > 0 {
+			fmt.Println(newline + "  ℹ Another gcp-ssh session is using this instance; it will handle auto-stop.")
+			return false
+		}
+		fmt.Printf("\r  ⏳ %s in %2ds (Ctrl-C to keep it running)...", action, int(remaining.Round(time.Second).Seconds()))
+		newline = "\n"
+		select {
+		case <-ctx.Done():
+			os.Remove(watchPath(sessionKey(inst)))
+			fmt.Println("\n  ✓ Auto-stop cancelled. Instance left running.")
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchBrowserSession hands a launched browser session over to a background
+// watcher, which stops the instance once it has been idle for the window.
+func watchBrowserSession(inst Instance, restore *instanceShape) {
+	delay, enabled, err := parseAutoStop(inst.AutoStop)
+	if err != nil {
+		fmt.Printf("  ⚠ Ignoring auto-stop: %v\n", err)
+		return
+	}
+	if !enabled {
+		return
+	}
+	if delay == 0 {
+		fmt.Println("  ℹ auto_stop=immediately only applies to terminal sessions; browser sessions need an idle window such as 30m.")
+		return
+	}
+	if _, err := os.Stat(googleComputeKeyPath()); err != nil {
+		fmt.Println("  ⚠ Auto-stop checks for browser sessions over gcloud compute ssh, which is not set up on this machine yet.")
+		fmt.Printf("    Connect once with 'gcp-ssh connect-terminal %s' to set it up; until then the instance is never stopped.\n", inst.Alias)
+	}
+	startAutoStopWatch(inst, delay, restore)
+}
+
+// ─── Idle watcher ────────────────────────────────────────────────────────────
+
+// An auto-stop window is watched by a detached gcp-ssh process, so the
+// command (or the TUI) returns at once and closing the terminal does not
+// cancel it. Every poll the watcher counts the instance as active while a
+// gcp-ssh session on this machine is connected to it, or while anyone has
+// an interactive login on it (browser SSH included), and stops it once
+// neither has been seen for the whole window. When activity cannot be
+// determined the instance counts as active.
+//
+// Each pending auto-stop is a file in ~/.gcp-ssh/watchers. A newer watcher
+// for the same instance replaces an older one, and `gcp-ssh keep` cancels
+// it by removing the file.
+
+// autoStopWatchCommand is the hidden command a watcher process runs.
+const autoStopWatchCommand = "__auto-stop-watch"
+
+// autoStopPollInterval is how often a watcher checks for activity, for
+// windows long enough; shorter windows are checked more often.
+const autoStopPollInterval = 2 * time.Minute
+
+// autoStopWatch is the state of a pending auto-stop.
+type autoStopWatch struct {
+	PID      int            `json:"pid"` // 0 until the watcher has started
+	Instance Instance       `json:"instance"`
+	Delay    string         `json:"delay"`
+	Restore  *instanceShape `json:"restore,omitempty"` // applied after stopping
+	Since    time.Time      `json:"since"`
+}
+
+func getWatchersDir() string {
+	dir := getDataPath("watchers")
+	os.MkdirAll(dir, 0755)
+	return dir
+}
+
+func watchPath(key string) string {
+	return filepath.Join(getWatchersDir(), key+".json")
+}
+
+func loadWatch(key string) (autoStopWatch, bool) {
+	var w autoStopWatch
+	data, err := os.ReadFile(watchPath(key))
+	if err != nil || json.Unmarshal(data, &w) != nil {
+		return w, false
+	}
+	return w, true
+}
+
+func saveWatch(key string, w autoStopWatch) {
+	data, _ := json.MarshalIndent(w, "", "  ")
+	os.WriteFile(watchPath(key), data, 0644)
+}
+
+// startAutoStopWatch starts a detached watcher for inst, replacing any
+// pending one.
+func startAutoStopWatch(inst Instance, delay time.Duration, restore *instanceShape) {
+	if dryRunSkip("start a background watcher that stops '%s' after %s without SSH sessions", inst.Name, delay) {
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Printf("  ⚠ Auto-stop not started: %v\n", err)
+		return
+	}
+	key := sessionKey(inst)
+	w := autoStopWatch{Instance: inst, Delay: delay.String(), Restore: restore, Since: time.Now()}
+	saveWatch(key, w)
+
+	cmd := exec.Command(exe, autoStopWatchCommand, key)
+	cmd.SysProcAttr = detachedProcAttr()
+	if err := cmd.Start(); err != nil {
+		os.Remove(watchPath(key))
+		fmt.Printf("  ⚠ Auto-stop not started: %v\n", err)
+		return
+	}
+	w.PID = cmd.Process.Pid
+	saveWatch(key, w)
+	cmd.Process.Release()
+	logger.Info("auto-stop watcher started", "name", inst.Name, "pid", w.PID, "delay", delay)
+	fmt.Printf("  ⏱ '%s' will be stopped once nobody has been connected to it for %s (background watcher, pid %d).\n",
+		inst.Name, delay, w.PID)
+	fmt.Printf("    To keep it running: gcp-ssh keep %s\n", inst.Alias)
+}
+
+// runAutoStopWatch is the body of a watcher process.
+func runAutoStopWatch(key string) {
+	w, ok := loadWatch(key)
+	if !ok {
+		return
+	}
+	delay, err := time.ParseDuration(w.Delay)
+	if err != nil || delay <= 0 {
+		logger.Warn("auto-stop watcher: invalid delay", "delay", w.Delay)
+		return
+	}
+	inst := w.Instance
+	interval := min(autoStopPollInterval, max(delay/4, 15*time.Second))
+	lastActive := time.Now()
+	logger.Info("auto-stop watcher running", "name", inst.Name, "delay", delay, "interval", interval)
+
+	for {
+		time.Sleep(interval)
+		current, ok := loadWatch(key)
+		if !ok || (current.PID != 0 && current.PID != os.Getpid()) {
+			logger.Info("auto-stop watcher cancelled or replaced", "name", inst.Name)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), gcloudQueryTimeout)
+		status, err := runGcloudValueCommand(ctx, withAccount(inst, "compute", "instances", "describe", inst.Name,
+			"--project", inst.Project,
+			"--zone", inst.Zone,
+			"--format=value(status)")...)
+		cancel()
+		if err == nil && !strings.EqualFold(status, "RUNNING") {
+			logger.Info("auto-stop watcher: instance no longer running", "name", inst.Name, "status", status)
+			os.Remove(watchPath(key))
+			return
+		}
+		if err != nil || otherActiveSessions(inst) > 0 {
+			lastActive = time.Now()
+			continue
+		}
+		if active, err := remoteLoginActive(context.Background(), inst); active || err != nil {
+			if err != nil {
+				logger.Warn("auto-stop watcher: could not check logins", "name", inst.Name, "err", err)
+			}
+			lastActive = time.Now()
+			continue
+		}
+		if time.Since(lastActive) < delay {
+			continue
+		}
+
+		logger.Info("auto-stop watcher: idle window passed, stopping", "name", inst.Name, "idle", time.Since(lastActive))
+		os.Remove(watchPath(key))
+		if stopInstance(context.Background(), inst) {
+			restoreShape(context.Background(), inst, w.Restore)
+		}
+		return
+	}
+}
+
+// remoteLoginActive reports whether anyone has an interactive login on the
+// instance, browser SSH included. The check itself runs without a terminal,
+// so it does not count.
+func remoteLoginActive(ctx context.Context, inst Instance) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, gcloudQueryTimeout)
+	defer cancel()
+	output, err := runGcloudValueCommand(ctx, withAccount(inst, "compute", "ssh", inst.Name,
+		"--project", inst.Project,
+		"--zone", inst.Zone,
+		"--command", "who | wc -l")...)
+	if err != nil {
+		return false, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(output))
+	if err != nil {
+		return false, fmt.Errorf("unexpected output of who: %q", output)
+	}
+	return n > 0, nil
+}
+
+// googleComputeKeyPath is the SSH key gcloud compute ssh uses.
+func googleComputeKeyPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ssh", "google_compute_engine")
+}
+
+// keepCommand implements `gcp-ssh keep <alias>`: it cancels a pending
+// auto-stop.
+func keepCommand(config *Config, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	inst, ok := resolveAlias(config, args[0])
+	if !ok {
+		return errNotFound
+	}
+	key := sessionKey(inst)
+	w, ok := loadWatch(key)
+	if !ok {
+		fmt.Printf("  ℹ No auto-stop is pending for '%s'.\n", inst.Name)
+		return nil
+	}
+	if dryRunSkip("cancel the auto-stop of '%s' (watcher pid %d)", inst.Name, w.PID) {
+		return nil
+	}
+	os.Remove(watchPath(key))
+	fmt.Printf("  ✓ Auto-stop of '%s' cancelled. It stays running.\n", inst.Name)
+	return nil
+}
+
+// ─── Session tracking ────────────────────────────────────────────────────────
+
+// Each running session leaves a marker file named <project>_<zone>_<name>.<pid>
+// in ~/.gcp-ssh/sessions so auto-stop can tell whether an instance is in use.
+
+func getSessionsDir() string {
+	dir := getDataPath("sessions")
+	os.MkdirAll(dir, 0755)
+	return dir
+}
+
+func sessionKey(inst Instance) string {
+	return inst.Project + "_" + inst.Zone + "_" + inst.Name
+}
+
+// trackSession marks the instance as in use by this process and returns a
+// function that clears the mark.
+func trackSession(inst Instance) func() {
+	if options.dryRun {
+		return func() {}
+	}
+	path := filepath.Join(getSessionsDir(), fmt.Sprintf("%s.%d", sessionKey(inst), os.Getpid()))
+	os.WriteFile(path, []byte(time.Now().Format(time.RFC3339)), 0644)
+	return func() { os.Remove(path) }
+}
+
+// otherActiveSessions counts live sessions for the instance owned by other
+// processes, removing markers left behind by processes that have exited.
+func otherActiveSessions(inst Instance) int {
+	return countSessions(inst, false)
+}
+
+// activeSessions counts live sessions for the instance, this process's
+// included.
+func activeSessions(inst Instance) int {
+	return countSessions(inst, true)
+}
+
+func countSessions(inst Instance, includeSelf bool) int {
+	dir := getSessionsDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	prefix := sessionKey(inst) + "."
+	count := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+		if err != nil || (pid == os.Getpid() && !includeSelf) {
+			continue
+		}
+		if pid != os.Getpid() && !processAlive(pid) {
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess only succeeds for running processes on Windows.
+		proc.Release()
+		return true
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
+}
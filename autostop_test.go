@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseAutoStop(t *testing.T) {
+	tests := []struct {
+		policy      string
+		wantDelay   time.Duration
+		wantEnabled bool
+		wantErr     bool
+	}{
+		{policy: ""},
+		{policy: "never"},
+		{policy: "immediately", wantEnabled: true},
+		{policy: "30", wantDelay: 30 * time.Minute, wantEnabled: true},
+		{policy: "45m", wantDelay: 45 * time.Minute, wantEnabled: true},
+		{policy: "1h30m", wantDelay: 90 * time.Minute, wantEnabled: true},
+		{policy: "0", wantErr: true},
+		{policy: "-5", wantErr: true},
+		{policy: "0s", wantErr: true},
+		{policy: "-1h", wantErr: true},
+		{policy: "Never", wantErr: true},
+		{policy: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			delay, enabled, err := parseAutoStop(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAutoStop(%q) error = %v, want error %v", tt.policy, err, tt.wantErr)
+			}
+			if delay != tt.wantDelay || enabled != tt.wantEnabled {
+				t.Errorf("parseAutoStop(%q) = %v, %v; want %v, %v", tt.policy, delay, enabled, tt.wantDelay, tt.wantEnabled)
+			}
+		})
+	}
+}
+
+func TestAutoStopCountdown(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	inst := Instance{Project: "my-project", Zone: "us-central1-a", Name: "dev"}
+
+	t.Run("Ctrl-C keeps the instance running", func(t *testing.T) {
+		saveWatch(sessionKey(inst), autoStopWatch{Instance: inst, Delay: "30m"})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if autoStopCountdown(ctx, inst, "Stopping 'dev'", time.Minute) {
+			t.Fatal("countdown went ahead after Ctrl-C")
+		}
+		if _, ok := loadWatch(sessionKey(inst)); ok {
+			t.Error("pending watcher was not cancelled")
+		}
+	})
+
+	t.Run("another session takes over", func(t *testing.T) {
+		// The test's parent process stands in for another live gcp-ssh.
+		marker := filepath.Join(getSessionsDir(), fmt.Sprintf("%s.%d", sessionKey(inst), os.Getppid()))
+		if err := os.WriteFile(marker, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(marker)
+		if autoStopCountdown(context.Background(), inst, "Stopping 'dev'", time.Minute) {
+			t.Error("countdown went ahead while another session uses the instance")
+		}
+	})
+
+	t.Run("countdown runs out", func(t *testing.T) {
+		if !autoStopCountdown(context.Background(), inst, "Stopping 'dev'", 0) {
+			t.Error("countdown did not go ahead")
+		}
+	})
+}
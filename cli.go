@@ -0,0 +1,659 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ─── Global flags ────────────────────────────────────────────────────────────
+
+// globalOptions holds the flags accepted by every command.
+type globalOptions struct {
+	configPath string
+	verbose    bool
+	debug      bool
+	dryRun     bool
+	output     string
+}
+
+var options = globalOptions{output: "text"}
+
+// parseGlobalFlags removes the global flags from args, wherever they appear
+// before a "--", and stores them in options.
+func parseGlobalFlags(args []string) ([]string, error) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") {
+			rest = append(rest, arg)
+			continue
+		}
+		takeValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("flag %s needs a value", arg)
+			}
+			i++
+			return args[i], nil
+		}
+		// Switches take no separate value, but --dry-run=false is honored.
+		takeBool := func() (bool, error) {
+			if !hasValue {
+				return true, nil
+			}
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return false, fmt.Errorf("invalid value '%s' for flag %s (use true or false)", value, strings.TrimSuffix(arg, "="+value))
+			}
+			return b, nil
+		}
+
+		var err error
+		switch name {
+		case "config":
+			options.configPath, err = takeValue()
+		case "verbose", "v":
+			options.verbose, err = takeBool()
+		case "debug":
+			options.debug, err = takeBool()
+		case "dry-run":
+			options.dryRun, err = takeBool()
+		case "output", "o":
+			if options.output, err = takeValue(); err == nil {
+				err = validateOutput(options.output)
+			}
+		default:
+			rest = append(rest, arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rest, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// traceCommand logs a command about to run. Read-only commands still run in
+// a dry run so the plan can follow the real instance status; they are
+// printed to stderr unless the log already goes there.
+func traceCommand(cmd *exec.Cmd) {
+	line := commandLine(cmd)
+	logger.Info("running command", "cmd", line)
+	if options.dryRun && !options.verbose && !options.debug {
+		fmt.Fprintf(os.Stderr, "  $ %s\n", line)
+	}
+}
+
+// skipCommand reports whether a state-changing command must not run because
+// of --dry-run, printing the command line instead.
+func skipCommand(cmd *exec.Cmd) bool {
+	if options.dryRun {
+		logger.Info("dry run, not running command", "cmd", commandLine(cmd))
+		fmt.Printf("  [dry-run] would run: %s\n", commandLine(cmd))
+		return true
+	}
+	traceCommand(cmd)
+	return false
+}
+
+// finishCommand logs how a command ended and attaches its stderr to a
+// failure. A command cut short by ctx fails with errInterrupted or
+// errTimedOut.
+func finishCommand(ctx context.Context, cmd *exec.Cmd, err error, stderr string) error {
+	if err == nil {
+		logger.Debug("command succeeded", "cmd", commandLine(cmd), "stderr", stderr)
+		return nil
+	}
+	if ctxErr := contextError(ctx); ctxErr != nil {
+		logger.Warn("command cancelled", "cmd", commandLine(cmd), "reason", ctxErr, "stderr", stderr)
+		return &gcloudError{Command: commandLine(cmd), Stderr: stderr, Err: ctxErr}
+	}
+	gerr := newGcloudError(commandLine(cmd), stderr, err)
+	logger.Warn("command failed", "cmd", gerr.Command, "err", err, "kind", gerr.Kind, "detail", gerr.Detail, "stderr", stderr)
+	return gerr
+}
+
+// dryRunSkip reports whether a local change must be skipped because of
+// --dry-run, describing it instead.
+func dryRunSkip(format string, args ...any) bool {
+	if options.dryRun {
+		fmt.Printf("  [dry-run] would "+format+"\n", args...)
+	}
+	return options.dryRun
+}
+
+// commandLine renders cmd as a shell command line, quoting where needed.
+func commandLine(cmd *exec.Cmd) string {
+	parts := make([]string, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'$&;|<>()*?") {
+			arg = strconv.Quote(arg)
+		}
+		parts[i] = arg
+	}
+	return strings.Join(parts, " ")
+}
+
+// ─── Exit codes ──────────────────────────────────────────────────────────────
+
+// Exit codes returned by the CLI. Failures without a more specific code exit
+// with exitFailure.
+const (
+	exitOK       = 0
+	exitFailure  = 1
+	exitUsage    = 2
+	exitNotFound = 3
+	exitAuth     = 4
+	exitStart    = 5
+	exitSSH      = 6
+	exitSignal   = 130 // interrupted by Ctrl-C, as shells report it
+)
+
+// Command errors. Commands print their own messages; the returned error only
+// selects the exit code.
+var (
+	errUsage    = errors.New("invalid usage")
+	errNotFound = errors.New("not found")
+	errFailed   = errors.New("command failed")
+)
+
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errInterrupted):
+		return exitSignal
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, errNotFound):
+		return exitNotFound
+	case errors.Is(err, errAuthFailed):
+		return exitAuth
+	case errors.Is(err, errStatusFailed), errors.Is(err, errStartFailed):
+		return exitStart
+	case errors.Is(err, errSSHFailed), errors.Is(err, errBrowserFailed):
+		return exitSSH
+	default:
+		return exitFailure
+	}
+}
+
+// ─── Command tree ────────────────────────────────────────────────────────────
+
+// command is a subcommand of gcp-ssh.
+type command struct {
+	name    string
+	args    string // usage after the command name
+	summary string
+	help    string // details shown by `gcp-ssh <command> --help`
+	run     func(config *Config, configPath string, args []string) error
+
+	// Used by shell completion: the command's flags, and the kind of value
+	// each positional argument takes (see completionCandidates).
+	flags    []string
+	argKinds []string
+}
+
+// instanceFlagNames are the flags of add, edit and clone.
+var instanceFlagNames = []string{"--project", "--zone", "--name", "--authuser", "--account", "--mode", "--auto-stop", "--tags", "--relocate-zones", "--session"}
+
+// connectFlagNames are the flags of connect and connect-terminal.
+var connectFlagNames = []string{"--machine-type", "--profile", "--restore", "--reconnect"}
+
+// commands is filled in by init because the help command refers to it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{
+			name:    "connect",
+			args:    "<alias> [--machine-type TYPE] [--profile NAME] [--restore] [--reconnect]",
+			summary: "Connect to a saved instance in its preferred mode",
+			help: `The alias may be a unique prefix; an ambiguous one opens the picker in a terminal.
+
+Flags:
+  --machine-type TYPE  switch a stopped instance to TYPE (e.g. n2-standard-16)
+                       before starting it; a running instance is left as is
+  --profile NAME       reshape a stopped instance to one of its saved profiles
+                       (machine type and GPUs); --machine-type overrides the type
+  --restore            undo the change when auto-stop stops the instance
+                       (needs auto_stop)
+  --reconnect          reopen a terminal session whose connection drops (ssh
+                       exit 255), checking the instance first and backing off;
+                       logging out or a command's own exit status ends it`,
+			flags:    connectFlagNames,
+			argKinds: []string{"alias"},
+			run: func(config *Config, configPath string, args []string) error {
+				return connectCommand(config, configPath, args, "")
+			},
+		},
+		{
+			name:     "connect-terminal",
+			args:     "<alias> [--machine-type TYPE] [--profile NAME] [--restore] [--reconnect]",
+			summary:  "Connect to a saved instance in terminal mode",
+			help:     "Accepts the same flags as connect.",
+			flags:    connectFlagNames,
+			argKinds: []string{"alias"},
+			run: func(config *Config, configPath string, args []string) error {
+				return connectCommand(config, configPath, args, "terminal")
+			},
+		},
+		{
+			name:    "last",
+			summary: "Reconnect to the most recently used instance",
+			run: func(config *Config, configPath string, args []string) error {
+				if len(args) != 0 {
+					return errUsage
+				}
+				return connectLast(config, configPath)
+			},
+		},
+		{
+			name:     "quick",
+			args:     "<project> <zone> <instance-name> [gcloud-account-email]",
+			summary:  "Connect to an unsaved instance in browser mode",
+			argKinds: []string{"project", "zone", "", "account"},
+			run: func(config *Config, configPath string, args []string) error {
+				return quickConnect(config, configPath, args, "")
+			},
+		},
+		{
+			name:     "quick-terminal",
+			args:     "<project> <zone> <instance-name> [gcloud-account-email]",
+			summary:  "Connect to an unsaved instance in terminal mode",
+			argKinds: []string{"project", "zone", "", "account"},
+			run: func(config *Config, configPath string, args []string) error {
+				return quickConnect(config, configPath, args, "terminal")
+			},
+		},
+		{
+			name:    "list",
+			summary: "List saved instances (most recently used first)",
+			help:    "Supports --output; see 'gcp-ssh help' for the formats.",
+			run: func(config *Config, configPath string, args []string) error {
+				if len(args) != 0 {
+					return errUsage
+				}
+				return listCommand(config)
+			},
+		},
+		{
+			name:     "show",
+			args:     "<alias>",
+			summary:  "Show every saved setting of an instance",
+			help:     "Supports --output, printing the same record as 'list'.",
+			argKinds: []string{"alias"},
+			run: func(config *Config, configPath string, args []string) error {
+				return showCommand(config, args)
+			},
+		},
+		{
+			name:     "status",
+			args:     "[<alias>]",
+			summary:  "Show the live status of saved instances",
+			help:     "Asks gcloud for the status and uptime of one saved instance, or of all.\nSupports --output, printing the same records as 'idle-report'.",
+			argKinds: []string{"alias"},
+			run: func(config *Config, configPath string, args []string) error {
+				return statusCommand(config, args)
+			},
+		},
+		{
+			name:    "add",
+			args:    "[<alias> --project P --zone Z --name N [flags]]",
+			summary: "Add a saved instance",
+			help: `Without arguments every field is prompted for. With an alias, fields come
+from flags and only missing required ones are prompted for (in a terminal).
+
+Flags:
+  --project P        GCP project ID
+  --zone Z           zone, e.g. us-central1-a
+  --name N           instance name
+  --authuser N       auth user index for the browser URL (default 0)
+  --account EMAIL    Google account for gcloud
+  --mode MODE        browser or terminal
+  --auto-stop POLICY never, immediately, or minutes without sessions
+  --tags a,b         comma separated tags
+  --relocate-zones Z1,Z2
+                     zones to move the instance to when its zone is out of
+                     capacity
+  --session S        attach terminal mode to a remote tmux or screen session:
+                     none, tmux, screen, tmux:NAME or screen:NAME`,
+			flags: instanceFlagNames,
+			run:   addCommand,
+		},
+		{
+			name:     "edit",
+			args:     "<alias> [flags]",
+			summary:  "Change fields of a saved instance",
+			help:     "Accepts the same flags as add and only changes the fields given. Without\nflags every field is prompted for (in a terminal).",
+			flags:    instanceFlagNames,
+			argKinds: []string{"alias"},
+			run:      editCommand,
+		},
+		{
+			name:     "rename",
+			args:     "<old-alias> <new-alias>",
+			summary:  "Rename a saved instance (history follows)",
+			argKinds: []string{"alias"},
+			run: func(config *Config, configPath string, args []string) error {
+				if len(args) != 2 {
+					return errUsage
+				}
+				return renameInstance(config, configPath, args[0], args[1])
+			},
+		},
+		{
+			name:     "clone",
+			args:     "<alias> <new-alias> [flags]",
+			summary:  "Copy a saved instance, overriding fields with add flags",
+			flags:    instanceFlagNames,
+			argKinds: []string{"alias"},
+			run:      cloneCommand,
+		},
+		{
+			name:     "remove",
+			args:     "<alias>",
+			summary:  "Remove a saved instance",
+			argKinds: []string{"alias"},
+			run: func(config *Config, configPath string, args []string) error {
+				if len(args) != 1 {
+					return errUsage
+				}
+				return removeInstance(config, configPath, args[0])
+			},
+		},
+		{
+			name:    "keep",
+			args:    "<alias>",
+			summary: "Cancel a pending auto-stop of an instance",
+			help: `An auto_stop window such as 30m is watched by a background gcp-ssh process
+that stops the instance once nobody has been connected to it for that long.
+keep cancels it; the next session with auto-stop starts a new one.`,
+			argKinds: []string{"alias"},
+			run: func(config *Config, configPath string, args []string) error {
+				return keepCommand(config, args)
+			},
+		},
+		{
+			name:    "resize",
+			args:    "<alias> <machine-type> [--stop]",
+			summary: "Change the machine type of a stopped instance",
+			help: `The instance must be stopped; --stop stops it first if it is running. It keeps
+the new type until it is resized again.`,
+			flags:    []string{"--stop"},
+			argKinds: []string{"alias", ""},
+			run: func(config *Config, configPath string, args []string) error {
+				return resizeCommand(config, args)
+			},
+		},
+		{
+			name:    "sessions",
+			args:    "<alias> [NAME]",
+			summary: "List remote tmux sessions and attach to one",
+			help: `Lists the tmux sessions on a running instance (screen sessions if the
+instance's session setting uses screen) and, in a terminal, asks which one
+to attach to. With NAME, attaches to that session directly, creating it if
+needed and starting the instance if it is stopped.`,
+			argKinds: []string{"alias", ""},
+			run:      sessionsCommand,
+		},
+		{
+			name:    "scratch",
+			args:    "<template|spec> [flags]",
+			summary: "Create a throwaway VM, SSH in, delete it on exit",
+			help: `Flags:
+  --machine-type T   override the machine type
+  --project P        project to create the instance in
+  --zone Z           zone to create the instance in
+  --account EMAIL    gcloud account to use
+  --keep             keep the instance after the session ends
+  --yes              delete the instance after the session without asking`,
+			flags:    []string{"--machine-type", "--project", "--zone", "--account", "--keep", "--yes"},
+			argKinds: []string{"scratch-spec"},
+			run:      scratchCommand,
+		},
+		{
+			name:    "scratch-cleanup",
+			args:    "[--yes]",
+			summary: "Delete scratch VMs left behind by earlier sessions",
+			flags:   []string{"--yes"},
+			run: func(config *Config, configPath string, args []string) error {
+				return scratchCleanup(args)
+			},
+		},
+		{
+			name:    "stats",
+			args:    "[--since 7d] [--json]",
+			summary: "Summarize sessions, wait times and failures",
+			help:    "--since accepts 7d, 2w, 36h or a date such as 2024-05-01. Supports --output;\n--json is the same as --output json.",
+			flags:   []string{"--since", "--json"},
+			run: func(config *Config, configPath string, args []string) error {
+				return statsCommand(args)
+			},
+		},
+		{
+			name:    "idle-report",
+			args:    "[--discover] [--threshold 4h] [--stop-idle] [--yes]",
+			summary: "Show running instances, uptime and last connection",
+			help: `Flags:
+  --discover         also include unsaved instances in the projects of saved ones
+  --threshold D      idle after this long without a start or connection (default 4h)
+  --stop-idle        offer to stop idle instances
+  --yes              stop idle instances without asking`,
+			flags: []string{"--discover", "--threshold", "--stop-idle", "--yes"},
+			run:   idleReport,
+		},
+		{
+			name:    "zones",
+			args:    "[--refresh] [--project P]",
+			summary: "List known zones; --refresh caches the live list",
+			flags:   []string{"--refresh", "--project"},
+			run: func(config *Config, configPath string, args []string) error {
+				return zonesCommand(args)
+			},
+		},
+		{
+			name:    "profile",
+			summary: "Change Chrome profile",
+			run: func(config *Config, configPath string, args []string) error {
+				if len(args) != 0 {
+					return errUsage
+				}
+				setChromeProfile(config, configPath)
+				return nil
+			},
+		},
+		{
+			name:     "completion",
+			args:     "<bash|zsh|fish>",
+			summary:  "Print a shell completion script",
+			help:     "bash:  source <(gcp-ssh completion bash)\nzsh:   source <(gcp-ssh completion zsh)\nfish:  gcp-ssh completion fish | source",
+			argKinds: []string{"shell"},
+			run: func(config *Config, configPath string, args []string) error {
+				return completionCommand(args)
+			},
+		},
+		{
+			name:     "help",
+			args:     "[command]",
+			summary:  "Show help for gcp-ssh or a command",
+			argKinds: []string{"command"},
+			run: func(config *Config, configPath string, args []string) error {
+				switch len(args) {
+				case 0:
+					printHelp()
+					return nil
+				case 1:
+					if cmd, ok := findCommand(args[0]); ok {
+						printCommandHelp(cmd)
+						return nil
+					}
+					fmt.Printf("  ✗ Unknown command '%s'.\n", args[0])
+				}
+				return errUsage
+			},
+		},
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// runCommand dispatches args to a command. A first argument that is not a
+// command but names a saved instance connects to it.
+func runCommand(args []string, config *Config, configPath string) error {
+	name := args[0]
+	if name == "-h" || name == "--help" {
+		printHelp()
+		return nil
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		if len(args) == 1 && matchesAlias(config, name) {
+			return connectByAlias(config, configPath, name, "", connectOptions{})
+		}
+		printUnknownCommand(config, name)
+		return errUsage
+	}
+
+	if wantsHelp(args[1:]) {
+		printCommandHelp(cmd)
+		return nil
+	}
+	logger.Info("command started", "command", cmd.name, "args", args[1:])
+	err := cmd.run(config, configPath, args[1:])
+	logger.Info("command finished", "command", cmd.name, "exit_code", exitCode(err), "err", err)
+	if errors.Is(err, errUsage) {
+		fmt.Printf("Usage: gcp-ssh %s\n", strings.TrimSpace(cmd.name+" "+cmd.args))
+		fmt.Printf("Run 'gcp-ssh %s --help' for details.\n", cmd.name)
+	}
+	return err
+}
+
+// matchesAlias reports whether name is a saved alias or a prefix of one, so
+// the `gcp-ssh <alias>` shorthand never mistakes a mistyped command for one.
+func matchesAlias(config *Config, name string) bool {
+	for _, inst := range config.Instances {
+		if strings.HasPrefix(strings.ToLower(inst.Alias), strings.ToLower(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsHelp reports whether -h or --help appears before a "--".
+func wantsHelp(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return false
+		case "-h", "-help", "--help":
+			return true
+		}
+	}
+	return false
+}
+
+// printUnknownCommand reports an argument that is neither a command nor a
+// saved alias, suggesting the closest of either.
+func printUnknownCommand(config *Config, name string) {
+	var suggestions []string
+	limit := max(2, len([]rune(name))/3)
+	for _, cmd := range commands {
+		if editDistance(name, cmd.name) <= limit {
+			suggestions = append(suggestions, cmd.name)
+		}
+	}
+	suggestions = append(suggestions, suggestAliases(config, name)...)
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	if len(suggestions) > 0 {
+		fmt.Printf("  ✗ Unknown command or alias '%s'. Did you mean: %s?\n", name, strings.Join(suggestions, ", "))
+		return
+	}
+	fmt.Printf("  ✗ Unknown command or alias '%s'. Run 'gcp-ssh help' for commands or 'gcp-ssh list' for aliases.\n", name)
+}
+
+func printCommandHelp(cmd command) {
+	fmt.Printf("Usage: gcp-ssh %s\n\n%s.\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.summary)
+	if cmd.help != "" {
+		fmt.Printf("\n%s\n", cmd.help)
+	}
+	fmt.Println("\nRun 'gcp-ssh help' for global flags and exit codes.")
+}
+
+func printHelp() {
+	fmt.Println()
+	fmt.Println("GCP SSH Launcher (Browser + Terminal)")
+	fmt.Println("=====================================")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Printf("  %-40s  %s\n", "gcp-ssh", "Interactive mode (full-screen in a terminal)")
+	fmt.Printf("  %-40s  %s\n", "gcp-ssh <alias>", "Connect to a saved instance by alias (or unique prefix)")
+	for _, cmd := range commands {
+		usage := strings.TrimSpace("gcp-ssh " + cmd.name + " " + cmd.args)
+		if len(usage) > 40 {
+			fmt.Printf("  %s\n  %-40s  %s\n", usage, "", cmd.summary)
+			continue
+		}
+		fmt.Printf("  %-40s  %s\n", usage, cmd.summary)
+	}
+	os.Stdout.WriteString(`
+Global flags (accepted anywhere before "--"):
+  --config PATH                             Use another config file
+  -v, --verbose                             Log the gcloud commands being run to stderr
+  --debug                                   Also log gcloud stderr and every step to stderr
+  --dry-run                                 Print the gcloud and browser commands that would change
+                                            something instead of running them; nothing is saved
+  -o, --output FORMAT                       Output of list, show, status, stats,
+                                            idle-report, sessions and zones:
+                                            text (default), table, tsv, json, yaml, or
+                                            template=GO-TEMPLATE executed per row
+
+Exit codes:
+  0 success, 1 other failure, 2 invalid usage, 3 alias not found,
+  4 gcloud authentication failed, 5 instance could not be started,
+  6 SSH session or browser launch failed, 130 interrupted with Ctrl-C
+
+Before SSH, the tool now:
+  1) verifies gcloud CLI is installed,
+  2) verifies/sets the expected gcloud account,
+  3) checks and starts the instance if not running.
+
+Saved instances with auto_stop=immediately are stopped when the terminal
+session ends. With a window such as 30m, a background watcher stops them once
+nobody has been connected for that long; 'gcp-ssh keep <alias>' cancels it.
+Either way a 30-second countdown runs first; Ctrl-C keeps the instance running.
+
+Config is stored at: ~/.gcp-ssh/config.json
+A debug log for bug reports is kept at: ~/.gcp-ssh/logs/gcp-ssh.log
+`)
+}
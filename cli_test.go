@@ -0,0 +1,99 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantRest    []string
+		wantOptions globalOptions
+		wantErr     bool
+	}{
+		{
+			name:        "no flags",
+			args:        []string{"connect", "dev"},
+			wantRest:    []string{"connect", "dev"},
+			wantOptions: globalOptions{output: "text"},
+		},
+		{
+			name:        "flags anywhere",
+			args:        []string{"-v", "connect", "--dry-run", "dev", "--reconnect"},
+			wantRest:    []string{"connect", "dev", "--reconnect"},
+			wantOptions: globalOptions{output: "text", verbose: true, dryRun: true},
+		},
+		{
+			name:        "values separate and joined",
+			args:        []string{"--config", "/tmp/c.json", "list", "-o=json"},
+			wantRest:    []string{"list"},
+			wantOptions: globalOptions{configPath: "/tmp/c.json", output: "json"},
+		},
+		{
+			name:        "template output",
+			args:        []string{"list", "--output", "template={{.Alias}}"},
+			wantRest:    []string{"list"},
+			wantOptions: globalOptions{output: "template={{.Alias}}"},
+		},
+		{
+			name:        "nothing parsed after --",
+			args:        []string{"--debug", "connect", "--", "--dry-run"},
+			wantRest:    []string{"connect", "--", "--dry-run"},
+			wantOptions: globalOptions{output: "text", debug: true},
+		},
+		{
+			name:        "explicit switch values",
+			args:        []string{"--verbose=false", "--dry-run=true", "--debug=0", "list"},
+			wantRest:    []string{"list"},
+			wantOptions: globalOptions{output: "text", dryRun: true},
+		},
+		{
+			name:        "later switch wins",
+			args:        []string{"-v", "list", "-v=false"},
+			wantRest:    []string{"list"},
+			wantOptions: globalOptions{output: "text"},
+		},
+		{
+			name:    "invalid switch value",
+			args:    []string{"list", "--dry-run=maybe"},
+			wantErr: true,
+		},
+		{
+			name:    "missing value",
+			args:    []string{"list", "--output"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid output",
+			args:    []string{"list", "-o", "xml"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid template",
+			args:    []string{"list", "-o", "template={{.Alias"},
+			wantErr: true,
+		},
+	}
+	saved := options
+	t.Cleanup(func() { options = saved })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options = globalOptions{output: "text"}
+			rest, err := parseGlobalFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGlobalFlags(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("parseGlobalFlags(%q) = %q, want %q", tt.args, rest, tt.wantRest)
+			}
+			if options != tt.wantOptions {
+				t.Errorf("options = %+v, want %+v", options, tt.wantOptions)
+			}
+		})
+	}
+}
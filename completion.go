@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// completeCommand is the hidden command the completion scripts call back
+// into. It receives the words typed after "gcp-ssh", the last one being the
+// word under the cursor, and prints one candidate per line.
+const completeCommand = "__complete"
+
+// valueFlags lists the flags that take a value, mapped to the kind of value
+// completed for them ("" for free text).
+var valueFlags = map[string]string{
+	"config":         "",
+	"output":         "output",
+	"o":              "output",
+	"project":        "project",
+	"zone":           "zone",
+	"name":           "",
+	"authuser":       "",
+	"account":        "account",
+	"mode":           "mode",
+	"auto-stop":      "auto-stop",
+	"tags":           "tags",
+	"relocate-zones": "zone-list",
+	"machine-type":   "",
+	"profile":        "instance-profile",
+	"session":        "session",
+	"since":          "",
+	"threshold":      "",
+}
+
+var globalFlagNames = []string{"--config", "--verbose", "--debug", "--dry-run", "--output", "--help"}
+
+// completionCandidates returns the values of a kind of argument.
+func completionCandidates(config *Config, kind string) []string {
+	var values []string
+	switch kind {
+	case "command":
+		for _, cmd := range commands {
+			values = append(values, cmd.name)
+		}
+	case "alias":
+		for _, inst := range sortedInstances(config) {
+			values = append(values, inst.Alias)
+		}
+	case "scratch-spec":
+		for _, spec := range config.ScratchSpecs {
+			values = append(values, spec.Alias)
+		}
+	case "tags":
+		for _, inst := range config.Instances {
+			values = append(values, inst.Tags...)
+		}
+	case "project":
+		for _, inst := range config.Instances {
+			values = append(values, inst.Project)
+		}
+		for _, spec := range config.ScratchSpecs {
+			values = append(values, spec.Project)
+		}
+		for _, entry := range loadHistory() {
+			values = append(values, entry.Project)
+		}
+	case "zone":
+		values = knownZones()
+	case "account":
+		for _, inst := range config.Instances {
+			values = append(values, inst.GcloudAccount)
+		}
+		for _, entry := range loadHistory() {
+			values = append(values, entry.Account)
+		}
+	case "session":
+		values = []string{"none", "tmux", "screen"}
+	case "instance-profile":
+		for _, inst := range config.Instances {
+			values = append(values, profileNames(inst)...)
+		}
+	case "mode":
+		values = []string{"browser", "terminal"}
+	case "output":
+		values = outputFormats
+	case "auto-stop":
+		values = []string{"never", "immediately", "15m", "30m", "1h"}
+	case "shell":
+		values = []string{"bash", "zsh", "fish"}
+	}
+	return values
+}
+
+// complete returns the candidates for the last of words.
+func complete(config *Config, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current, previous := words[len(words)-1], words[:len(words)-1]
+
+	// Find the command and the positional arguments given so far.
+	var cmd command
+	hasCommand := false
+	positional := 0
+	expectValue := ""
+	valueKind := ""
+	for _, word := range previous {
+		if expectValue != "" {
+			expectValue = ""
+			continue
+		}
+		if strings.HasPrefix(word, "-") {
+			name, _, hasValue := strings.Cut(strings.TrimLeft(word, "-"), "=")
+			if _, ok := valueFlags[name]; ok && !hasValue {
+				expectValue, valueKind = name, valueFlags[name]
+			}
+			continue
+		}
+		if !hasCommand {
+			cmd, hasCommand = findCommand(word)
+			if !hasCommand {
+				return nil // `gcp-ssh <alias>` takes no further arguments
+			}
+			continue
+		}
+		positional++
+	}
+
+	var kind string
+	switch {
+	case expectValue != "":
+		kind = valueKind
+	case strings.HasPrefix(current, "-"):
+		flags := append([]string(nil), globalFlagNames...)
+		if hasCommand {
+			flags = append(flags, cmd.flags...)
+		}
+		return filterPrefix(flags, current)
+	case !hasCommand:
+		return filterPrefix(append(completionCandidates(config, "command"), completionCandidates(config, "alias")...), current)
+	case positional < len(cmd.argKinds):
+		kind = cmd.argKinds[positional]
+	}
+
+	if kind == "tags" || kind == "zone-list" {
+		// Complete the last element of a comma separated list.
+		done := ""
+		if i := strings.LastIndex(current, ","); i >= 0 {
+			done, current = current[:i+1], current[i+1:]
+		}
+		chosen := strings.Split(done, ",")
+		var values []string
+		listKind := kind
+		if kind == "zone-list" {
+			listKind = "zone"
+		}
+		for _, tag := range filterPrefix(completionCandidates(config, listKind), current) {
+			if !contains(chosen, tag) {
+				values = append(values, done+tag)
+			}
+		}
+		return values
+	}
+	return filterPrefix(completionCandidates(config, kind), current)
+}
+
+// filterPrefix returns the distinct non-empty values starting with prefix, in
+// their original order.
+func filterPrefix(values []string, prefix string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, v := range values {
+		if v != "" && !seen[v] && strings.HasPrefix(v, prefix) {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// printCompletions implements the hidden __complete command. It runs before
+// the global flags are parsed, so it picks up --config from the words itself.
+func printCompletions(words []string) {
+	configPath := getConfigPath()
+	for i, word := range words[:max(len(words)-1, 0)] {
+		if value, ok := strings.CutPrefix(word, "--config="); ok {
+			configPath = value
+		} else if word == "--config" && i+1 < len(words)-1 {
+			configPath = words[i+1]
+		}
+	}
+	for _, candidate := range complete(loadConfig(configPath), words) {
+		fmt.Println(candidate)
+	}
+}
+
+// ─── Shell scripts ───────────────────────────────────────────────────────────
+
+const bashCompletion = `# bash completion for gcp-ssh. Load with:
+#   source <(gcp-ssh completion bash)
+_gcp_ssh() {
+    local IFS=$'\n'
+    COMPREPLY=($(gcp-ssh __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _gcp_ssh gcp-ssh
+`
+
+const zshCompletion = `#compdef gcp-ssh
+# zsh completion for gcp-ssh. Load with:
+#   source <(gcp-ssh completion zsh)
+# or save it as _gcp-ssh in a directory on $fpath.
+_gcp_ssh() {
+    local -a candidates
+    candidates=(${(f)"$(gcp-ssh __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    compadd -a candidates
+}
+if [ "$funcstack[1]" = "_gcp_ssh" ]; then
+    _gcp_ssh "$@"
+else
+    compdef _gcp_ssh gcp-ssh
+fi
+`
+
+const fishCompletion = `# fish completion for gcp-ssh. Load with:
+#   gcp-ssh completion fish | source
+# or save it as ~/.config/fish/completions/gcp-ssh.fish
+function __gcp_ssh_complete
+    set -l words (commandline -opc)[2..-1] (commandline -ct)
+    gcp-ssh __complete $words 2>/dev/null
+end
+complete -c gcp-ssh -f -a '(__gcp_ssh_complete)'
+`
+
+// completionCommand implements `gcp-ssh completion <bash|zsh|fish>`.
+func completionCommand(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	scripts := map[string]string{"bash": bashCompletion, "zsh": zshCompletion, "fish": fishCompletion}
+	script, ok := scripts[args[0]]
+	if !ok {
+		fmt.Printf("  ✗ Unsupported shell '%s' (use bash, zsh or fish).\n", args[0])
+		return errUsage
+	}
+	fmt.Print(script)
+	return nil
+}
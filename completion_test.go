@@ -0,0 +1,45 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	// History and the zone cache live under HOME; keep them empty.
+	t.Setenv("HOME", t.TempDir())
+	config := &Config{
+		Instances: []Instance{
+			{Alias: "dev", Project: "my-project", Zone: "us-central1-a", Name: "dev", Tags: []string{"gpu", "team-a"},
+				Profiles: map[string]InstanceProfile{"gpu": {}, "cpu": {}}},
+			{Alias: "devbox", Project: "my-project", Zone: "europe-west1-b", Name: "box", Tags: []string{"team-b"}},
+		},
+		ScratchSpecs: []ScratchSpec{{Alias: "tmp"}},
+	}
+	tests := []struct {
+		name  string
+		words []string
+		want  []string
+	}{
+		{name: "alias prefix", words: []string{"dev"}, want: []string{"dev", "devbox"}},
+		{name: "command prefix", words: []string{"conn"}, want: []string{"connect", "connect-terminal"}},
+		{name: "alias argument", words: []string{"connect", "devb"}, want: []string{"devbox"}},
+		{name: "no second alias", words: []string{"connect", "dev", "d"}, want: nil},
+		{name: "nothing after an alias", words: []string{"dev", ""}, want: nil},
+		{name: "command flag", words: []string{"connect", "dev", "--pro"}, want: []string{"--profile"}},
+		{name: "global flag", words: []string{"list", "--dr"}, want: []string{"--dry-run"}},
+		{name: "output value", words: []string{"list", "-o", "y"}, want: []string{"yaml"}},
+		{name: "joined flag value is not awaited", words: []string{"list", "--output=json", "--c"}, want: []string{"--config"}},
+		{name: "profile value", words: []string{"connect", "dev", "--profile", "g"}, want: []string{"gpu"}},
+		{name: "tag list", words: []string{"edit", "dev", "--tags", "gpu,team-"}, want: []string{"gpu,team-a", "gpu,team-b"}},
+		{name: "scratch spec", words: []string{"scratch", "t"}, want: []string{"tmp"}},
+		{name: "shell", words: []string{"completion", "z"}, want: []string{"zsh"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := complete(config, tt.words); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("complete(%q) = %q, want %q", tt.words, got, tt.want)
+			}
+		})
+	}
+}
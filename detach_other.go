@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package main
+
+import "syscall"
+
+// detachedProcAttr returns no attributes: without sessions, a started
+// process already outlives its parent.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package main
+
+import "syscall"
+
+// detachedProcAttr starts a process in its own session, so it outlives the
+// terminal that started it.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ─── gcloud errors ───────────────────────────────────────────────────────────
+
+// Kinds of gcloud failure that have a specific remedy. A classified
+// gcloudError matches its kind with errors.Is.
+var (
+	errPermissionDenied = errors.New("permission denied")
+	errResourceNotFound = errors.New("resource not found")
+	errZoneExhausted    = errors.New("zone resources exhausted")
+	errQuotaExceeded    = errors.New("quota exceeded")
+	errReauthRequired   = errors.New("reauthentication required")
+	errAPIDisabled      = errors.New("API not enabled")
+	errTransient        = errors.New("temporary server error")
+)
+
+// gcloudError is a failed external command together with what it wrote to
+// stderr, so the reason reaches the error message, history and log.
+type gcloudError struct {
+	Command string // the command line that failed
+	Stderr  string
+	Err     error
+	Kind    error  // one of the kinds above, or nil if unrecognised
+	Detail  string // the missing permission, exhausted quota or disabled API
+}
+
+// newGcloudError wraps a failed command and classifies its stderr.
+func newGcloudError(command, stderr string, err error) *gcloudError {
+	kind, detail := classifyGcloudError(stderr)
+	return &gcloudError{Command: command, Stderr: stderr, Err: err, Kind: kind, Detail: detail}
+}
+
+func (e *gcloudError) Error() string {
+	reason := stderrSummary(e.Stderr)
+	if apiErr, ok := parseAPIError(e.Stderr); ok && apiErr.Error.Message != "" {
+		reason = apiErr.Error.Message
+	}
+	if reason != "" {
+		return fmt.Sprintf("%v: %s", e.Err, reason)
+	}
+	return e.Err.Error()
+}
+
+func (e *gcloudError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Err, e.Kind}
+}
+
+var (
+	requiredPermissionPattern = regexp.MustCompile(`Required '([a-zA-Z0-9_.]+)' permission`)
+	deniedPermissionPattern   = regexp.MustCompile(`Permission '([a-zA-Z0-9_.]+)' denied`)
+	missingAccessPattern      = regexp.MustCompile(`does not have ([a-zA-Z0-9_.]+) access`)
+	quotaPattern              = regexp.MustCompile(`Quota '([A-Za-z0-9_]+)' exceeded`)
+	serverErrorPattern        = regexp.MustCompile(`HTTPError 5[0-9][0-9]|"code": ?5[0-9][0-9]`)
+	disabledAPIPattern        = regexp.MustCompile(`API \[([a-z0-9.-]+)\] not enabled|([a-z0-9-]+\.googleapis\.com) (?:has not been used|is disabled)`)
+)
+
+// classifyGcloudError recognises the failures gcloud reports either as text
+// or as an embedded JSON API error, returning the kind and, where gcloud
+// names it, the permission, quota metric or API involved.
+func classifyGcloudError(stderr string) (kind error, detail string) {
+	text := stderr
+	if apiErr, ok := parseAPIError(stderr); ok {
+		text += "\n" + apiErr.Error.Status + "\n" + apiErr.Error.Message
+		for _, d := range apiErr.Error.Details {
+			text += "\n" + d.Reason
+			if service := d.Metadata["service"]; service != "" && d.Reason == "SERVICE_DISABLED" {
+				detail = service
+			}
+			if permission := d.Metadata["permission"]; permission != "" {
+				detail = permission
+			}
+		}
+	}
+
+	switch {
+	case strings.Contains(text, "Reauthentication required") ||
+		strings.Contains(text, "reauth related error") ||
+		strings.Contains(text, "problem refreshing your current auth tokens") ||
+		strings.Contains(text, "invalid_grant"):
+		return errReauthRequired, ""
+	case strings.Contains(text, "SERVICE_DISABLED") || strings.Contains(text, "has not been used in project") ||
+		disabledAPIPattern.MatchString(text):
+		if m := disabledAPIPattern.FindStringSubmatch(text); m != nil {
+			detail = m[1] + m[2]
+		}
+		return errAPIDisabled, detail
+	case strings.Contains(text, "ZONE_RESOURCE_POOL_EXHAUSTED") ||
+		strings.Contains(text, "does not have enough resources available"):
+		return errZoneExhausted, ""
+	case serverErrorPattern.MatchString(text) || strings.Contains(text, "backendError") ||
+		strings.Contains(text, "UNAVAILABLE") || strings.Contains(text, "Internal Error") ||
+		strings.Contains(text, "rateLimitExceeded") || strings.Contains(text, "RATE_LIMIT_EXCEEDED") ||
+		strings.Contains(text, "Operation rate exceeded") || strings.Contains(text, "is not ready"):
+		return errTransient, ""
+	case strings.Contains(text, "QUOTA_EXCEEDED") || quotaPattern.MatchString(text):
+		if m := quotaPattern.FindStringSubmatch(text); m != nil {
+			detail = m[1]
+		}
+		return errQuotaExceeded, detail
+	case strings.Contains(text, "PERMISSION_DENIED") || requiredPermissionPattern.MatchString(text) ||
+		deniedPermissionPattern.MatchString(text) || missingAccessPattern.MatchString(text):
+		for _, p := range []*regexp.Regexp{requiredPermissionPattern, deniedPermissionPattern, missingAccessPattern} {
+			if m := p.FindStringSubmatch(text); m != nil {
+				detail = m[1]
+				break
+			}
+		}
+		return errPermissionDenied, detail
+	case strings.Contains(text, "was not found") || strings.Contains(text, "NOT_FOUND") ||
+		strings.Contains(text, "HTTPError 404"):
+		return errResourceNotFound, ""
+	}
+	return nil, ""
+}
+
+// apiError is the JSON error body of a Google API, which gcloud sometimes
+// prints as is.
+type apiError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			Reason   string            `json:"reason"`
+			Metadata map[string]string `json:"metadata"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// parseAPIError decodes the outermost JSON object in stderr, if any.
+func parseAPIError(stderr string) (apiError, bool) {
+	var result apiError
+	start, end := strings.Index(stderr, "{"), strings.LastIndex(stderr, "}")
+	if start < 0 || end < start {
+		return result, false
+	}
+	if err := json.Unmarshal([]byte(stderr[start:end+1]), &result); err != nil {
+		return result, false
+	}
+	return result, result.Error.Status != "" || result.Error.Message != ""
+}
+
+// printGcloudHint prints what the user can do about a classified gcloud
+// failure affecting inst. Unclassified errors print nothing.
+func printGcloudHint(err error, inst Instance) {
+	var gerr *gcloudError
+	if !errors.As(err, &gerr) || gerr.Kind == nil {
+		return
+	}
+	switch gerr.Kind {
+	case errPermissionDenied:
+		account := inst.GcloudAccount
+		if account == "" {
+			account = "The active account"
+		}
+		if gerr.Detail != "" {
+			fmt.Printf("  💡 %s lacks the '%s' permission on project '%s'. Ask a project owner for a role that includes it (e.g. roles/compute.instanceAdmin.v1).\n", account, gerr.Detail, inst.Project)
+		} else {
+			fmt.Printf("  💡 %s lacks permission on project '%s'. Check its IAM roles, or set the right gcloud_account for this alias.\n", account, inst.Project)
+		}
+	case errResourceNotFound:
+		fmt.Printf("  💡 No instance '%s' in zone '%s' of project '%s'. Check the saved name and zone with: gcloud compute instances list --project %s\n", inst.Name, inst.Zone, inst.Project, inst.Project)
+	case errZoneExhausted:
+		fmt.Printf("  💡 Zone '%s' has no capacity for this machine type right now. Retry in a few minutes or move the instance to another zone.\n", inst.Zone)
+	case errQuotaExceeded:
+		quota := "A quota"
+		if gerr.Detail != "" {
+			quota = fmt.Sprintf("Quota '%s'", gerr.Detail)
+		}
+		fmt.Printf("  💡 %s is used up. Stop other instances or request more at https://console.cloud.google.com/iam-admin/quotas?project=%s\n", quota, inst.Project)
+	case errReauthRequired:
+		if inst.GcloudAccount != "" {
+			fmt.Printf("  💡 gcloud credentials have expired. Run 'gcloud auth login %s' and retry.\n", inst.GcloudAccount)
+		} else {
+			fmt.Println("  💡 gcloud credentials have expired. Run 'gcloud auth login' and retry.")
+		}
+	case errTransient:
+		fmt.Println("  💡 Google Cloud reported a temporary error. Try again in a minute.")
+	case errAPIDisabled:
+		api := gerr.Detail
+		if api == "" {
+			api = "compute.googleapis.com"
+		}
+		fmt.Printf("  💡 The %s API is not enabled in project '%s'. Enable it with: gcloud services enable %s --project %s\n", api, inst.Project, api, inst.Project)
+	}
+}
+
+// stderrSummary returns the last few non-empty lines of stderr on one line.
+func stderrSummary(stderr string) string {
+	const maxLines = 3
+	var lines []string
+	for _, line := range strings.Split(stderr, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	return strings.Join(lines, " ")
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf.Write(p)
+	if extra := t.buf.Len() - t.max; extra > 0 {
+		t.buf.Next(extra)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	return t.buf.String()
+}
+
+// stderrCapacity bounds how much stderr is kept from a command whose output
+// is also shown to the user.
+const stderrCapacity = 8 << 10
+
+// captureStderr tees a command's stderr into a bounded buffer while passing
+// it through to w.
+func captureStderr(w io.Writer) (io.Writer, *tailBuffer) {
+	tail := &tailBuffer{max: stderrCapacity}
+	return io.MultiWriter(w, tail), tail
+}
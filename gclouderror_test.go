@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestClassifyGcloudError(t *testing.T) {
+	tests := []struct {
+		name       string
+		stderr     string
+		wantKind   error
+		wantDetail string
+	}{
+		{
+			name: "zone exhausted with details",
+			stderr: `ERROR: (gcloud.compute.instances.start) Could not fetch resource:
+ - {
+  "code": "ZONE_RESOURCE_POOL_EXHAUSTED_WITH_DETAILS",
+  "message": "The zone 'projects/my-project/zones/us-central1-a' does not have enough resources available to fulfill the request.  '(resource type:compute)'."
+}
+`,
+			wantKind: errZoneExhausted,
+		},
+		{
+			name: "zone exhausted as text",
+			stderr: `ERROR: (gcloud.compute.instances.start) Could not fetch resource:
+ - The zone 'projects/my-project/zones/us-central1-a' does not have enough resources available to fulfill the request.  Try a different zone, or try again later.
+`,
+			wantKind: errZoneExhausted,
+		},
+		{
+			name: "permission denied JSON body",
+			stderr: `ERROR: (gcloud.compute.instances.start) HTTPError 403: {
+  "error": {
+    "code": 403,
+    "message": "Required 'compute.instances.start' permission for 'projects/my-project/zones/us-central1-a/instances/dev'",
+    "errors": [
+      {
+        "message": "Required 'compute.instances.start' permission for 'projects/my-project/zones/us-central1-a/instances/dev'",
+        "domain": "global",
+        "reason": "forbidden"
+      }
+    ],
+    "status": "PERMISSION_DENIED",
+    "details": [
+      {
+        "@type": "type.googleapis.com/google.rpc.ErrorInfo",
+        "reason": "IAM_PERMISSION_DENIED",
+        "domain": "iam.googleapis.com",
+        "metadata": {
+          "permission": "compute.instances.start"
+        }
+      }
+    ]
+  }
+}
+`,
+			wantKind:   errPermissionDenied,
+			wantDetail: "compute.instances.start",
+		},
+		{
+			name: "permission denied as text",
+			stderr: `ERROR: (gcloud.compute.instances.describe) Could not fetch resource:
+ - Required 'compute.instances.get' permission for 'projects/my-project/zones/us-central1-a/instances/dev'
+`,
+			wantKind:   errPermissionDenied,
+			wantDetail: "compute.instances.get",
+		},
+		{
+			name: "reauthentication",
+			stderr: `ERROR: (gcloud.compute.instances.describe) There was a problem refreshing your current auth tokens: Reauthentication failed. cannot prompt during non-interactive execution.
+Please run:
+
+  $ gcloud auth login
+
+to obtain new credentials.
+`,
+			wantKind: errReauthRequired,
+		},
+		{
+			name:     "invalid grant",
+			stderr:   "ERROR: (gcloud.compute.instances.list) There was a problem refreshing your current auth tokens: ('invalid_grant: Bad Request', {'error': 'invalid_grant', 'error_description': 'Bad Request'})\n",
+			wantKind: errReauthRequired,
+		},
+		{
+			name:       "API not enabled",
+			stderr:     "ERROR: (gcloud.compute.instances.list) API [compute.googleapis.com] not enabled on project [123456789012]. Would you like to enable and retry (this will take a few minutes)? (y/N)?\n",
+			wantKind:   errAPIDisabled,
+			wantDetail: "compute.googleapis.com",
+		},
+		{
+			name: "quota exceeded",
+			stderr: `ERROR: (gcloud.compute.instances.start) Could not fetch resource:
+ - Quota 'NVIDIA_T4_GPUS' exceeded.  Limit: 1.0 in region us-central1.
+`,
+			wantKind:   errQuotaExceeded,
+			wantDetail: "NVIDIA_T4_GPUS",
+		},
+		{
+			name: "instance not found",
+			stderr: `ERROR: (gcloud.compute.instances.describe) Could not fetch resource:
+ - The resource 'projects/my-project/zones/us-central1-a/instances/dev' was not found
+`,
+			wantKind: errResourceNotFound,
+		},
+		{
+			name:     "server error",
+			stderr:   "ERROR: (gcloud.compute.instances.start) HTTPError 503: The service is currently unavailable.\n",
+			wantKind: errTransient,
+		},
+		{
+			name:   "ssh connection failure",
+			stderr: "ssh: connect to host 34.0.0.1 port 22: Connection refused\nERROR: (gcloud.compute.ssh) [/usr/bin/ssh] exited with return code [255].\n",
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, detail := classifyGcloudError(tt.stderr)
+			if kind != tt.wantKind || detail != tt.wantDetail {
+				t.Errorf("classifyGcloudError() = %v, %q; want %v, %q", kind, detail, tt.wantKind, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestGcloudErrorMatchesKindAndCause(t *testing.T) {
+	exitErr := &exec.ExitError{}
+	err := newGcloudError("gcloud compute instances start dev", "ERROR: (gcloud.compute.instances.start) HTTPError 503: unavailable", exitErr)
+	if !errors.Is(err, errTransient) {
+		t.Errorf("errors.Is(err, errTransient) = false, want true")
+	}
+	var target *exec.ExitError
+	if !errors.As(err, &target) || target != exitErr {
+		t.Errorf("errors.As(err, *exec.ExitError) did not find the cause")
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// HistoryEntry records a single connection attempt.
+type HistoryEntry struct {
+	Time            time.Time `json:"time"`
+	Alias           string    `json:"alias,omitempty"`
+	Project         string    `json:"project"`
+	Zone            string    `json:"zone"`
+	Name            string    `json:"name"`
+	Mode            string    `json:"mode"`
+	Account         string    `json:"account,omitempty"`
+	Outcome         string    `json:"outcome"` // ok or failed
+	Error           string    `json:"error,omitempty"`
+	ErrorCategory   string    `json:"error_category,omitempty"`
+	WaitSeconds     float64   `json:"wait_seconds"`     // gcloud checks and instance start-up
+	DurationSeconds float64   `json:"duration_seconds"` // session length; 0 for browser sessions
+}
+
+func getHistoryPath() string {
+	return getDataPath("history.jsonl")
+}
+
+// recordHistory appends a connection attempt to the history log. waited is
+// the start-up time spent before the session was launched.
+func recordHistory(inst Instance, mode string, start time.Time, waited time.Duration, err error) {
+	entry := HistoryEntry{
+		Time:        start,
+		Alias:       inst.Alias,
+		Project:     inst.Project,
+		Zone:        inst.Zone,
+		Name:        inst.Name,
+		Mode:        mode,
+		Account:     inst.GcloudAccount,
+		Outcome:     "ok",
+		WaitSeconds: waited.Seconds(),
+	}
+	if mode == "terminal" {
+		entry.DurationSeconds = (time.Since(start) - waited).Seconds()
+	}
+	if err != nil {
+		entry.Outcome = "failed"
+		entry.Error = err.Error()
+		entry.ErrorCategory = errorCategory(err)
+	}
+
+	if options.dryRun {
+		return
+	}
+	f, ferr := os.OpenFile(getHistoryPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if ferr != nil {
+		return
+	}
+	defer f.Close()
+	data, _ := json.Marshal(entry)
+	f.Write(append(data, '\n'))
+}
+
+// loadHistory returns all recorded connection attempts, oldest first.
+func loadHistory() []HistoryEntry {
+	f, err := os.Open(getHistoryPath())
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// renameHistoryAlias rewrites history entries recorded under oldAlias to use
+// newAlias.
+func renameHistoryAlias(oldAlias, newAlias string) error {
+	entries := loadHistory()
+	changed := false
+	for i := range entries {
+		if entries[i].Alias == oldAlias {
+			entries[i].Alias = newAlias
+			changed = true
+		}
+	}
+	if !changed || options.dryRun {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		data, _ := json.Marshal(entry)
+		buf.Write(append(data, '\n'))
+	}
+	// Write to a temporary file first so an interrupted rename cannot truncate
+	// the log.
+	tmp := getHistoryPath() + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, getHistoryPath())
+}
+
+// loadLastConnected returns when each instance was last used through
+// gcp-ssh, keyed by sessionKey. Entries are written when a session ends and
+// their time is its start, so a session counts as activity until its end.
+func loadLastConnected() map[string]time.Time {
+	last := map[string]time.Time{}
+	for _, entry := range loadHistory() {
+		key := sessionKey(Instance{Project: entry.Project, Zone: entry.Zone, Name: entry.Name})
+		end := entry.Time.Add(time.Duration(entry.DurationSeconds * float64(time.Second)))
+		if end.After(last[key]) {
+			last[key] = end
+		}
+	}
+	return last
+}
+
+// ─── Most-recently-used ordering ─────────────────────────────────────────────
+
+// frecencyWeight scores a single use by how long ago it happened.
+func frecencyWeight(age time.Duration) float64 {
+	switch {
+	case age < time.Hour:
+		return 4
+	case age < 24*time.Hour:
+		return 2
+	case age < 7*24*time.Hour:
+		return 1
+	case age < 30*24*time.Hour:
+		return 0.5
+	default:
+		return 0.25
+	}
+}
+
+// sortedInstances returns the saved instances ordered by how recently and how
+// often they were used. Instances that were never used keep their saved order
+// after the used ones.
+func sortedInstances(config *Config) []Instance {
+	scores := map[string]float64{}
+	for _, entry := range loadHistory() {
+		if entry.Alias != "" {
+			scores[entry.Alias] += frecencyWeight(time.Since(entry.Time))
+		}
+	}
+
+	instances := append([]Instance(nil), config.Instances...)
+	sort.SliceStable(instances, func(i, j int) bool {
+		return scores[instances[i].Alias] > scores[instances[j].Alias]
+	})
+	return instances
+}
+
+// connectLast reconnects to the instance used most recently, in the mode that
+// was used then.
+func connectLast(config *Config, configPath string) error {
+	history := loadHistory()
+	if len(history) == 0 {
+		fmt.Println("  ✗ No connection history yet.")
+		return errNotFound
+	}
+	entry := history[len(history)-1]
+
+	inst := Instance{
+		Alias:         entry.Alias,
+		Project:       entry.Project,
+		Zone:          entry.Zone,
+		Name:          entry.Name,
+		GcloudAccount: entry.Account,
+	}
+	for _, saved := range config.Instances {
+		if entry.Alias != "" && saved.Alias == entry.Alias {
+			inst = saved
+			break
+		}
+	}
+	inst.ConnectionMode = entry.Mode
+
+	label := inst.Alias
+	if label == "" {
+		label = inst.Name
+	}
+	fmt.Printf("  ℹ Reconnecting to '%s' (%s mode)...\n", label, entry.Mode)
+	return openByMode(config, configPath, inst, connectOptions{})
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ─── Idle report ─────────────────────────────────────────────────────────────
+
+// defaultIdleThreshold is how long an instance must be up and unused before
+// idle-report and status flag it.
+const defaultIdleThreshold = 4 * time.Hour
+
+type idleReportRow struct {
+	Instance  Instance
+	Status    string
+	LastStart time.Time
+	LastConn  time.Time // end of the last gcp-ssh session
+	Sessions  int       // gcp-ssh sessions connected right now
+	Err       error
+}
+
+// idle reports whether a running instance has been up and unused for at
+// least the threshold, with no gcp-ssh session connected.
+func (r idleReportRow) idle(threshold time.Duration) bool {
+	if r.Err != nil || r.Status != "RUNNING" || r.LastStart.IsZero() || r.Sessions > 0 {
+		return false
+	}
+	if time.Since(r.LastStart) < threshold {
+		return false
+	}
+	return r.LastConn.IsZero() || time.Since(r.LastConn) >= threshold
+}
+
+func idleReport(config *Config, configPath string, args []string) error {
+	fs := flag.NewFlagSet("idle-report", flag.ContinueOnError)
+	discover := fs.Bool("discover", false, "also include unsaved instances in the projects of saved instances")
+	threshold := fs.Duration("threshold", defaultIdleThreshold, "uptime and time since last connection after which an instance counts as idle")
+	stopIdle := fs.Bool("stop-idle", false, "offer to stop idle instances")
+	yes := fs.Bool("yes", false, "stop idle instances without asking")
+	if _, err := parseFlags(fs, args); err != nil {
+		return errUsage
+	}
+
+	ctx, stop := interruptContext()
+	defer func() { stop() }()
+
+	instances := append([]Instance(nil), config.Instances...)
+	if *discover {
+		instances = append(instances, discoverInstances(ctx, config.Instances)...)
+	}
+	if len(instances) == 0 {
+		fmt.Println("  No saved instances.")
+		return nil
+	}
+
+	rows := loadActivity(ctx, instances)
+	if err := contextError(ctx); err != nil {
+		fmt.Println("  ✗ Interrupted.")
+		return err
+	}
+	if structuredOutput() {
+		if err := writeRecords(activityRecords(rows, *threshold)); err != nil || !*stopIdle {
+			return err
+		}
+	} else {
+		printActivity(rows, *threshold)
+	}
+
+	if !*stopIdle {
+		return nil
+	}
+	var idle []Instance
+	for _, row := range rows {
+		if row.idle(*threshold) {
+			idle = append(idle, row.Instance)
+		}
+	}
+	if len(idle) == 0 {
+		fmt.Printf("  ✓ No instances have been idle for %s or longer.\n", *threshold)
+		return nil
+	}
+	if !*yes {
+		stop() // the question should end on Ctrl-C as usual
+		fmt.Printf("  Stop %d idle instance(s)? (y/n): ", len(idle))
+		if strings.ToLower(readLine(bufio.NewReader(os.Stdin))) != "y" {
+			return nil
+		}
+		ctx, stop = interruptContext()
+	}
+	var err error
+	for _, inst := range idle {
+		if contextError(ctx) != nil {
+			fmt.Println("  ✗ Interrupted.")
+			return errInterrupted
+		}
+		// Browser SSH sessions leave no trace on this machine; look for them
+		// on the instance before stopping it.
+		if active, checkErr := remoteLoginActive(ctx, inst); checkErr != nil {
+			fmt.Printf("  ⚠ Not stopping '%s': could not check for logins on it: %v\n", inst.Name, checkErr)
+			continue
+		} else if active {
+			fmt.Printf("  ℹ Not stopping '%s': someone is logged in to it.\n", inst.Name)
+			continue
+		}
+		if !ensureGcloudAccount(ctx, inst.GcloudAccount) {
+			err = errAuthFailed
+		} else if !stopInstance(ctx, inst) {
+			err = errFailed
+		}
+	}
+	return err
+}
+
+// loadActivity reads the live status of each instance.
+func loadActivity(ctx context.Context, instances []Instance) []idleReportRow {
+	lastConnected := loadLastConnected()
+	var rows []idleReportRow
+	for _, inst := range instances {
+		row := idleReportRow{Instance: inst, LastConn: lastConnected[sessionKey(inst)], Sessions: activeSessions(inst)}
+		details, err := describeInstance(ctx, inst)
+		row.Status, row.LastStart, row.Err = details.Status, details.lastStart(), err
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// activityRecords converts idle report rows to their --output records.
+func activityRecords(rows []idleReportRow, threshold time.Duration) []activityRecord {
+	records := []activityRecord{}
+	for _, row := range rows {
+		record := activityRecord{
+			Alias:          row.Instance.Alias,
+			Project:        row.Instance.Project,
+			Zone:           row.Instance.Zone,
+			Name:           row.Instance.Name,
+			Status:         row.Status,
+			LastStart:      timeOrNil(row.LastStart),
+			LastConnected:  timeOrNil(row.LastConn),
+			ActiveSessions: row.Sessions,
+			Idle:           row.idle(threshold),
+		}
+		if row.Err != nil {
+			record.Status, record.Error = "UNKNOWN", row.Err.Error()
+		} else if row.Status == "RUNNING" && !row.LastStart.IsZero() {
+			record.UptimeSeconds = time.Since(row.LastStart).Round(time.Second).Seconds()
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// printActivity prints the idle report rows for people.
+func printActivity(rows []idleReportRow, threshold time.Duration) {
+	fmt.Println("  ┌─ Instance activity:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		alias := row.Instance.Alias
+		if alias == "" {
+			alias = "-"
+		}
+		status, uptime := row.Status, "-"
+		if row.Err != nil {
+			status = "UNKNOWN"
+		} else if status == "RUNNING" && !row.LastStart.IsZero() {
+			uptime = formatAge(time.Since(row.LastStart))
+		}
+		lastConn := "never"
+		if !row.LastConn.IsZero() {
+			lastConn = formatAge(time.Since(row.LastConn)) + " ago"
+		}
+		marker := ""
+		if row.Sessions > 0 {
+			marker = fmt.Sprintf("● %d session(s) connected", row.Sessions)
+		} else if row.idle(threshold) {
+			marker = "⚠ idle"
+		}
+		fmt.Fprintf(w, "  │  [%s]\t%s/%s/%s\t%s\tup %s\tlast used %s\t%s\n",
+			alias, row.Instance.Project, row.Instance.Zone, row.Instance.Name, status, uptime, lastConn, marker)
+	}
+	w.Flush()
+	fmt.Println("  └─")
+}
+
+// ─── Status ──────────────────────────────────────────────────────────────────
+
+// statusCommand implements `gcp-ssh status [alias]`: the live view of
+// idle-report for one saved instance, or all of them.
+func statusCommand(config *Config, args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	instances := sortedInstances(config)
+	if len(args) == 1 {
+		inst, ok := resolveAlias(config, args[0])
+		if !ok {
+			return errNotFound
+		}
+		instances = []Instance{inst}
+	}
+	if len(instances) == 0 {
+		if structuredOutput() {
+			return writeRecords([]activityRecord{})
+		}
+		fmt.Println("  No saved instances.")
+		return nil
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	rows := loadActivity(ctx, instances)
+	if err := contextError(ctx); err != nil {
+		fmt.Println("  ✗ Interrupted.")
+		return err
+	}
+	if structuredOutput() {
+		// Failures are reported in the error field of their record.
+		return writeRecords(activityRecords(rows, defaultIdleThreshold))
+	}
+	printActivity(rows, defaultIdleThreshold)
+	var err error
+	for _, row := range rows {
+		if row.Err != nil {
+			fmt.Printf("  ✗ [%s] %v\n", row.Instance.Alias, row.Err)
+			printGcloudHint(row.Err, row.Instance)
+			err = errStatusFailed
+		}
+	}
+	return err
+}
+
+// discoverInstances lists instances in the projects of saved instances that
+// are not saved themselves.
+func discoverInstances(ctx context.Context, saved []Instance) []Instance {
+	known := map[string]bool{}
+	for _, inst := range saved {
+		known[sessionKey(inst)] = true
+	}
+
+	var found []Instance
+	seenProject := map[string]bool{}
+	for _, inst := range saved {
+		if seenProject[inst.Project] {
+			continue
+		}
+		seenProject[inst.Project] = true
+
+		args := []string{"compute", "instances", "list", "--project", inst.Project, "--format=json"}
+		if inst.GcloudAccount != "" {
+			args = append(args, "--account", inst.GcloudAccount)
+		}
+		output, err := runGcloudValueCommand(ctx, args...)
+		if err != nil {
+			fmt.Printf("  ⚠ Could not list instances in project '%s': %v\n", inst.Project, err)
+			continue
+		}
+		var listed []instanceDetails
+		if err := json.Unmarshal([]byte(output), &listed); err != nil {
+			continue
+		}
+		for _, d := range listed {
+			candidate := Instance{
+				Project:       inst.Project,
+				Zone:          lastPathSegment(d.Zone),
+				Name:          d.Name,
+				GcloudAccount: inst.GcloudAccount,
+			}
+			if !known[sessionKey(candidate)] {
+				known[sessionKey(candidate)] = true
+				found = append(found, candidate)
+			}
+		}
+	}
+	return found
+}
+
+// lastPathSegment returns the final element of a GCP resource URL, e.g. the
+// zone name of ".../zones/us-central1-a".
+func lastPathSegment(url string) string {
+	return url[strings.LastIndex(url, "/")+1:]
+}
+
+// formatAge renders a duration compactly, e.g. "45m", "5h12m" or "3d4h".
+func formatAge(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%02dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Connecting runs a chain of gcloud calls. Each runs under a context that is
+// cancelled by Ctrl-C or SIGTERM and bounded by a per-step timeout, so a hung
+// gcloud cannot block forever and an interrupt reports what was in flight
+// instead of leaving it unexplained.
+
+const (
+	gcloudQueryTimeout = time.Minute     // describe, auth list, config set
+	gcloudStartTimeout = 5 * time.Minute // one start attempt, or a create
+	gcloudStopTimeout  = 5 * time.Minute // a stop or delete
+	gcloudGraceTimeout = 5 * time.Second // between the interrupt and a kill
+)
+
+var (
+	errInterrupted = errors.New("interrupted")
+	errTimedOut    = errors.New("timed out")
+)
+
+// interruptContext returns a context cancelled by Ctrl-C or SIGTERM. While it
+// is active those signals no longer end the process; stop restores that.
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// gcloudCommand prepares a gcloud invocation bound to ctx. On cancellation
+// gcloud is interrupted first so it can clean up, and killed after
+// gcloudGraceTimeout.
+func gcloudCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "gcloud", args...)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = gcloudGraceTimeout
+	return cmd
+}
+
+// contextError returns errInterrupted or errTimedOut if ctx has ended.
+func contextError(ctx context.Context) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return errTimedOut
+	case ctx.Err() != nil:
+		return errInterrupted
+	}
+	return nil
+}
+
+// reportInterrupted explains an interrupted connection: which command was
+// running and that Google Cloud may still complete it.
+func reportInterrupted(err error, inst Instance) {
+	if !errors.Is(err, errInterrupted) {
+		return
+	}
+	var gerr *gcloudError
+	if errors.As(err, &gerr) {
+		fmt.Printf("  ✗ Interrupted while running: %s\n", gerr.Command)
+	} else {
+		fmt.Println("  ✗ Interrupted.")
+	}
+	fmt.Printf("  ℹ Operations already sent to Google Cloud may still complete. Check with: gcloud compute instances describe %s --project %s --zone %s --format=\"value(status)\"\n",
+		inst.Name, inst.Project, inst.Zone)
+}
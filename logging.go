@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// User-facing messages are printed with fmt as before. The logger records
+// what happened underneath (gcloud invocations, their stderr, each readiness
+// step) in a debug log under ~/.gcp-ssh/logs, and mirrors it to stderr with
+// --verbose (info) or --debug (debug).
+
+// logger discards everything until setupLogging runs.
+var logger = slog.New(slog.DiscardHandler)
+
+const (
+	logFileName  = "gcp-ssh.log"
+	logMaxBytes  = 5 << 20 // rotate once the log reaches this size
+	logKeepFiles = 3       // rotated logs kept as gcp-ssh.log.1 .. .3
+)
+
+func getLogsDir() string {
+	dir := getDataPath("logs")
+	os.MkdirAll(dir, 0755)
+	return dir
+}
+
+// setupLogging configures logger from the global flags. The returned
+// function closes the log file.
+func setupLogging() func() {
+	var handlers []slog.Handler
+
+	if options.verbose || options.debug {
+		level := slog.LevelInfo
+		if options.debug {
+			level = slog.LevelDebug
+		}
+		handlers = append(handlers, slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: level,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && a.Key == slog.TimeKey {
+					return slog.Attr{} // the terminal does not need timestamps
+				}
+				return a
+			},
+		}))
+	}
+
+	closeFile := func() {}
+	path := filepath.Join(getLogsDir(), logFileName)
+	rotateLog(path)
+	if f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+		handlers = append(handlers, slog.NewJSONHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		closeFile = func() { f.Close() }
+	}
+
+	logger = slog.New(fanoutHandler(handlers)).With("pid", os.Getpid())
+	return closeFile
+}
+
+// rotateLog shifts path to path.1 (and so on) once it has grown past
+// logMaxBytes, dropping the oldest.
+func rotateLog(path string) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() < logMaxBytes {
+		return
+	}
+	os.Remove(fmt.Sprintf("%s.%d", path, logKeepFiles))
+	for i := logKeepFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	os.Rename(path, path+".1")
+}
+
+// fanoutHandler sends every record to all handlers that accept its level.
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, r.Level) {
+			errs = append(errs, handler.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	result := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		result[i] = handler.WithAttrs(attrs)
+	}
+	return result
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	result := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		result[i] = handler.WithGroup(name)
+	}
+	return result
+}
@@ -2,52 +2,97 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 )
 
 // Config holds saved instance configurations
 type Config struct {
-	ChromeProfileDir string     `json:"chrome_profile_dir"`
-	Instances        []Instance `json:"instances"`
+	ChromeProfileDir string        `json:"chrome_profile_dir"`
+	Instances        []Instance    `json:"instances"`
+	ScratchSpecs     []ScratchSpec `json:"scratch_specs,omitempty"`
 }
 
 // Instance holds GCP instance details
 type Instance struct {
-	Alias          string `json:"alias"`
-	Project        string `json:"project"`
-	Zone           string `json:"zone"`
-	Name           string `json:"name"`
-	AuthUser       int    `json:"authuser"`
-	GcloudAccount  string `json:"gcloud_account,omitempty"`
-	ConnectionMode string `json:"connection_mode,omitempty"` // browser or terminal
+	Alias          string       `json:"alias"`
+	Project        string       `json:"project"`
+	Zone           string       `json:"zone"`
+	Name           string       `json:"name"`
+	AuthUser       int          `json:"authuser"`
+	GcloudAccount  string       `json:"gcloud_account,omitempty"`
+	ConnectionMode string       `json:"connection_mode,omitempty"` // browser or terminal
+	AutoStop       string       `json:"auto_stop,omitempty"`       // never, immediately, or an idle duration such as 30m
+	Tags           []string     `json:"tags,omitempty"`
+	Retry          *RetryPolicy `json:"retry,omitempty"`          // retrying transient start failures
+	RelocateZones  []string     `json:"relocate_zones,omitempty"` // zones to move to when out of capacity
+
+	Profiles map[string]InstanceProfile `json:"profiles,omitempty"` // shapes for connect --profile
+	Session  string                     `json:"session,omitempty"`  // remote tmux/screen session for terminal mode
 }
 
 func main() {
-	configPath := getConfigPath()
-	config := loadConfig(configPath)
+	if len(os.Args) > 1 && os.Args[1] == completeCommand {
+		printCompletions(os.Args[2:])
+		return
+	}
 
-	if len(os.Args) > 1 {
-		handleArgs(os.Args[1:], config, configPath)
+	args, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		os.Exit(exitUsage)
+	}
+	configPath := options.configPath
+	if configPath == "" {
+		configPath = getConfigPath()
+	}
+	config := loadConfig(configPath)
+	closeLog := setupLogging()
+	if len(args) == 2 && args[0] == autoStopWatchCommand {
+		runAutoStopWatch(args[1])
+		closeLog()
 		return
 	}
+	if options.dryRun {
+		fmt.Println("  ℹ Dry run: commands that change state are printed, not run.")
+	}
+
+	if len(args) > 0 {
+		code := exitCode(runCommand(args, config, configPath))
+		closeLog()
+		os.Exit(code)
+	}
 
 	interactiveMode(config, configPath)
+	closeLog()
 }
 
 //  Config helpers
 
 func getConfigPath() string {
+	return getDataPath("config.json")
+}
+
+// getDataPath returns the path of a file inside ~/.gcp-ssh, creating the
+// directory if needed.
+func getDataPath(name string) string {
 	home, _ := os.UserHomeDir()
 	dir := filepath.Join(home, ".gcp-ssh")
 	os.MkdirAll(dir, 0755)
-	return filepath.Join(dir, "config.json")
+	return filepath.Join(dir, name)
 }
 
 func loadConfig(path string) *Config {
@@ -61,81 +106,18 @@ func loadConfig(path string) *Config {
 }
 
 func saveConfig(path string, config *Config) {
+	if dryRunSkip("save %s", path) {
+		return
+	}
 	data, _ := json.MarshalIndent(config, "", "  ")
 	os.WriteFile(path, data, 0644)
 }
 
-// ─── CLI argument handling ───────────────────────────────────────────────────
-
-func handleArgs(args []string, config *Config, configPath string) {
-	switch args[0] {
-	case "list":
-		listInstances(config)
-	case "add":
-		addInstance(config, configPath)
-	case "remove":
-		if len(args) < 2 {
-			fmt.Println("Usage: gcp-ssh remove <alias>")
-			return
-		}
-		removeInstance(config, configPath, args[1])
-	case "connect":
-		if len(args) < 2 {
-			fmt.Println("Usage: gcp-ssh connect <alias>")
-			return
-		}
-		connectByAlias(config, args[1], "")
-	case "connect-terminal":
-		if len(args) < 2 {
-			fmt.Println("Usage: gcp-ssh connect-terminal <alias>")
-			return
-		}
-		connectByAlias(config, args[1], "terminal")
-	case "quick":
-		if len(args) < 4 {
-			fmt.Println("Usage: gcp-ssh quick <project> <zone> <instance-name> [gcloud-account-email]")
-			return
-		}
-		inst := Instance{
-			Project:  args[1],
-			Zone:     args[2],
-			Name:     args[3],
-			AuthUser: 0,
-		}
-		if len(args) > 4 {
-			inst.GcloudAccount = args[4]
-		}
-		openSSH(config, inst)
-	case "quick-terminal":
-		if len(args) < 4 {
-			fmt.Println("Usage: gcp-ssh quick-terminal <project> <zone> <instance-name> [gcloud-account-email]")
-			return
-		}
-		inst := Instance{Project: args[1], Zone: args[2], Name: args[3], AuthUser: 0, ConnectionMode: "terminal"}
-		if len(args) > 4 {
-			inst.GcloudAccount = args[4]
-		}
-		connectTerminal(inst)
-	case "profile":
-		setChromeProfile(config, configPath)
-	case "help":
-		printHelp()
-	default:
-		// Try treating first arg as an alias
-		connectByAlias(config, args[0], "")
-	}
-}
-
 // ─── Interactive mode ────────────────────────────────────────────────────────
 
 func interactiveMode(config *Config, configPath string) {
 	reader := bufio.NewReader(os.Stdin)
 
-	fmt.Println("╔══════════════════════════════════════════╗")
-	fmt.Println("║      GCP SSH-in-Browser Launcher         ║")
-	fmt.Println("╚══════════════════════════════════════════╝")
-	fmt.Println()
-
 	// Check Chrome profile
 	if config.ChromeProfileDir == "" {
 		fmt.Println("⚠  No Chrome profile set. Let's configure it first.")
@@ -143,6 +125,16 @@ func interactiveMode(config *Config, configPath string) {
 		fmt.Println()
 	}
 
+	// Fall back to the numbered menu if the terminal cannot do the TUI.
+	if interactiveTerminal() && runTUI(config, configPath) {
+		return
+	}
+
+	fmt.Println("╔══════════════════════════════════════════╗")
+	fmt.Println("║      GCP SSH-in-Browser Launcher         ║")
+	fmt.Println("╚══════════════════════════════════════════╝")
+	fmt.Println()
+
 	for {
 		fmt.Println("┌─ What would you like to do?")
 		fmt.Println("│  1) Connect to a saved instance")
@@ -166,24 +158,40 @@ func interactiveMode(config *Config, configPath string) {
 			listInstances(config)
 			fmt.Print("  Enter alias or number: ")
 			input := readLine(reader)
-			// Try as number first
+			// Try as number first; numbers follow the most-recently-used order of the list
 			if num, err := strconv.Atoi(input); err == nil && num >= 1 && num <= len(config.Instances) {
-				openByMode(config, config.Instances[num-1])
+				openByMode(config, configPath, sortedInstances(config)[num-1], connectOptions{})
 			} else {
-				connectByAlias(config, input, "")
+				connectByAlias(config, configPath, input, "", connectOptions{})
 			}
 			fmt.Println()
 
 		case "2":
 			fmt.Println()
 			inst := promptInstanceDetails(reader)
-			openByMode(config, inst)
+			if err := validateTarget(inst); err != nil {
+				fmt.Printf("  ✗ %v\n\n", err)
+				// Back to the menu, unless input has ended.
+				if _, err := reader.Peek(1); err != nil {
+					return
+				}
+				continue
+			}
+			openByMode(config, configPath, inst, connectOptions{})
 			fmt.Println()
 
 			fmt.Print("  Save this instance for later? (y/n): ")
 			if strings.ToLower(readLine(reader)) == "y" {
 				fmt.Print("  Enter an alias: ")
 				inst.Alias = readLine(reader)
+				for err := validateAlias(config, inst.Alias); err != nil; err = validateAlias(config, inst.Alias) {
+					fmt.Printf("  ✗ %v\n", err)
+					fmt.Print("  Enter an alias: ")
+					var ok bool
+					if inst.Alias, ok = readInput(reader); !ok {
+						return
+					}
+				}
 				config.Instances = append(config.Instances, inst)
 				saveConfig(configPath, config)
 				fmt.Printf("  ✓ Saved as '%s'\n\n", inst.Alias)
@@ -226,21 +234,20 @@ func interactiveMode(config *Config, configPath string) {
 
 func promptInstanceDetails(reader *bufio.Reader) Instance {
 	inst := Instance{}
-	fmt.Print("  GCP Project ID: ")
-	inst.Project = readLine(reader)
-	fmt.Print("  Zone (e.g. us-central1-a): ")
-	inst.Zone = readLine(reader)
-	fmt.Print("  Instance Name: ")
-	inst.Name = readLine(reader)
-	fmt.Print("  Auth User index for browser URL (0 default, 1 second account, etc.) [0]: ")
-	authStr := readLine(reader)
-	if authStr == "" {
-		inst.AuthUser = 0
-	} else {
-		inst.AuthUser, _ = strconv.Atoi(authStr)
+	inst.Project = promptValid(reader, "GCP Project ID: ", validateProjectID)
+	inst.Zone = promptZone(reader, "Zone (e.g. us-central1-a, or a prefix like europe-west4)", "")
+	inst.Name = promptValid(reader, "Instance Name: ", validateInstanceName)
+	authStr := promptValid(reader, "Auth User index for browser URL (0 default, 1 second account, etc.) [0]: ", func(s string) error {
+		if s == "" {
+			return nil
+		}
+		_, err := validateAuthUser(s)
+		return err
+	})
+	if authStr != "" {
+		inst.AuthUser, _ = validateAuthUser(authStr)
 	}
-	fmt.Print("  Google account email for gcloud (recommended): ")
-	inst.GcloudAccount = readLine(reader)
+	inst.GcloudAccount = promptValid(reader, "Google account email for gcloud (recommended): ", validateAccount)
 	fmt.Print("  Preferred SSH mode [browser/terminal] (default browser): ")
 	mode := strings.ToLower(readLine(reader))
 	if mode == "terminal" {
@@ -248,39 +255,517 @@ func promptInstanceDetails(reader *bufio.Reader) Instance {
 	} else {
 		inst.ConnectionMode = "browser"
 	}
+	for {
+		fmt.Print("  Auto-stop after sessions end [never/immediately/<minutes>] (default never): ")
+		policy := strings.ToLower(readLine(reader))
+		if _, _, err := parseAutoStop(policy); err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			continue
+		}
+		inst.AutoStop = policy
+		break
+	}
+	fmt.Print("  Tags, comma separated (optional): ")
+	inst.Tags = parseTags(readLine(reader))
+	return inst
+}
+
+// editInstanceDetails prompts for every field of inst, keeping the current
+// value when the input is empty. Entering "-" clears an optional field.
+func editInstanceDetails(reader *bufio.Reader, inst Instance) Instance {
+	inst.Project = promptDefaultValid(reader, "GCP Project ID", inst.Project, validateProjectID)
+	inst.Zone = promptZone(reader, "Zone", inst.Zone)
+	inst.Name = promptDefaultValid(reader, "Instance Name", inst.Name, validateInstanceName)
+	authStr := promptDefaultValid(reader, "Auth User index for browser URL", strconv.Itoa(inst.AuthUser), func(s string) error {
+		_, err := validateAuthUser(s)
+		return err
+	})
+	if n, err := validateAuthUser(authStr); err == nil {
+		inst.AuthUser = n
+	}
+	inst.GcloudAccount = promptDefaultValid(reader, "Google account email for gcloud", inst.GcloudAccount, validateAccount)
+	mode := strings.ToLower(promptDefault(reader, "Preferred SSH mode [browser/terminal]", inst.ConnectionMode))
+	if mode == "terminal" {
+		inst.ConnectionMode = "terminal"
+	} else {
+		inst.ConnectionMode = "browser"
+	}
+	for {
+		policy := strings.ToLower(promptDefault(reader, "Auto-stop [never/immediately/<minutes>]", inst.AutoStop))
+		if _, _, err := parseAutoStop(policy); err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			continue
+		}
+		inst.AutoStop = policy
+		break
+	}
+	inst.Tags = parseTags(promptDefault(reader, "Tags, comma separated", strings.Join(inst.Tags, ",")))
 	return inst
 }
 
-func addInstance(config *Config, configPath string) {
+// promptDefault asks for a value showing the current one in brackets. Empty
+// input keeps the current value and "-" clears it.
+func promptDefault(reader *bufio.Reader, label, current string) string {
+	if current != "" {
+		fmt.Printf("  %s [%s]: ", label, current)
+	} else {
+		fmt.Printf("  %s: ", label)
+	}
+	switch input := readLine(reader); input {
+	case "":
+		return current
+	case "-":
+		return ""
+	default:
+		return input
+	}
+}
+
+func addInstance(config *Config, configPath string) error {
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Print("  Enter an alias (short name): ")
 	alias := readLine(reader)
 
-	// Check for duplicate alias
-	for _, inst := range config.Instances {
-		if inst.Alias == alias {
-			fmt.Printf("  ✗ Alias '%s' already exists.\n", alias)
-			return
-		}
+	if err := validateAlias(config, alias); err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		return errFailed
 	}
 
 	inst := promptInstanceDetails(reader)
 	inst.Alias = alias
+	if err := validateInstance(inst); err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		return errFailed
+	}
 	config.Instances = append(config.Instances, inst)
 	saveConfig(configPath, config)
 	fmt.Printf("  ✓ Instance '%s' saved.\n", alias)
+	return nil
 }
 
-func removeInstance(config *Config, configPath string, alias string) {
-	for i, inst := range config.Instances {
-		if inst.Alias == alias {
-			config.Instances = append(config.Instances[:i], config.Instances[i+1:]...)
-			saveConfig(configPath, config)
-			fmt.Printf("  ✓ Removed '%s'.\n", alias)
-			return
+// instanceFlags are the flags shared by `add` and `edit`.
+type instanceFlags struct {
+	project, zone, name, account, mode, autoStop, tags, relocateZones, session *string
+	authUser                                                                   *int
+}
+
+func newInstanceFlags(fs *flag.FlagSet) *instanceFlags {
+	return &instanceFlags{
+		project:  fs.String("project", "", "GCP project ID"),
+		zone:     fs.String("zone", "", "zone, e.g. us-central1-a"),
+		name:     fs.String("name", "", "instance name"),
+		authUser: fs.Int("authuser", 0, "auth user index for the browser URL"),
+		account:  fs.String("account", "", "Google account email for gcloud"),
+		mode:     fs.String("mode", "", "preferred SSH mode: browser or terminal"),
+		autoStop: fs.String("auto-stop", "", "never, immediately, or minutes without sessions"),
+		tags:     fs.String("tags", "", "comma separated tags"),
+
+		relocateZones: fs.String("relocate-zones", "", "comma separated zones to move to when out of capacity"),
+		session:       fs.String("session", "", "remote session for terminal mode: none, tmux, screen, tmux:NAME or screen:NAME"),
+	}
+}
+
+// apply copies the flags given on the command line into inst.
+func (f *instanceFlags) apply(fs *flag.FlagSet, inst *Instance) {
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "project":
+			inst.Project = *f.project
+		case "zone":
+			inst.Zone = *f.zone
+		case "name":
+			inst.Name = *f.name
+		case "authuser":
+			inst.AuthUser = *f.authUser
+		case "account":
+			inst.GcloudAccount = *f.account
+		case "mode":
+			inst.ConnectionMode = strings.ToLower(*f.mode)
+		case "auto-stop":
+			inst.AutoStop = strings.ToLower(*f.autoStop)
+		case "tags":
+			inst.Tags = parseTags(*f.tags)
+		case "relocate-zones":
+			inst.RelocateZones = parseTags(*f.relocateZones)
+		case "session":
+			inst.Session = strings.TrimSpace(*f.session)
+			if inst.Session == "none" {
+				inst.Session = ""
+			}
+		}
+	})
+}
+
+// validateInstance checks the fields of a saved instance.
+func validateInstance(inst Instance) error {
+	if missing := missingFields(inst); len(missing) > 0 {
+		return fmt.Errorf("missing required field(s): %s", strings.Join(missing, ", "))
+	}
+	if err := validateTarget(inst); err != nil {
+		return err
+	}
+	if inst.AuthUser < 0 {
+		return fmt.Errorf("authuser must be a non-negative number")
+	}
+	if err := validateMode(inst.ConnectionMode); err != nil {
+		return err
+	}
+	if _, _, err := parseAutoStop(inst.AutoStop); err != nil {
+		return err
+	}
+	if _, err := parseRetryPolicy(inst.Retry); err != nil {
+		return err
+	}
+	for _, zone := range inst.RelocateZones {
+		if err := validateZone(zone); err != nil {
+			return fmt.Errorf("relocate_zones: %w", err)
+		}
+	}
+	for _, name := range profileNames(inst) {
+		if err := validateProfile(name, inst.Profiles[name]); err != nil {
+			return err
+		}
+	}
+	if _, err := parseSessionSetting(inst.Session); err != nil {
+		return err
+	}
+	return nil
+}
+
+// missingFields returns the names of required fields that are empty.
+func missingFields(inst Instance) []string {
+	var missing []string
+	if inst.Project == "" {
+		missing = append(missing, "project")
+	}
+	if inst.Zone == "" {
+		missing = append(missing, "zone")
+	}
+	if inst.Name == "" {
+		missing = append(missing, "name")
+	}
+	return missing
+}
+
+// addCommand implements `gcp-ssh add [<alias> --project P --zone Z --name N ...]`.
+// Without arguments it prompts for everything; with an alias it only prompts
+// for missing required fields, and only when stdin is a terminal.
+func addCommand(config *Config, configPath string, args []string) error {
+	if len(args) == 0 {
+		return addInstance(config, configPath)
+	}
+
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	flags := newInstanceFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil || len(positional) != 1 {
+		return errUsage
+	}
+	alias := positional[0]
+	if err := validateAlias(config, alias); err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		return errFailed
+	}
+
+	inst := Instance{Alias: alias, ConnectionMode: "browser"}
+	flags.apply(fs, &inst)
+	if missing := missingFields(inst); len(missing) > 0 {
+		if !isTerminal(os.Stdin) || !promptMissingFields(bufio.NewReader(os.Stdin), &inst) {
+			fmt.Printf("  ✗ Missing required flag(s): --%s\n", strings.Join(missing, ", --"))
+			return errFailed
+		}
+	}
+	if err := validateInstance(inst); err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		return errFailed
+	}
+
+	config.Instances = append(config.Instances, inst)
+	saveConfig(configPath, config)
+	fmt.Printf("  ✓ Instance '%s' saved.\n", alias)
+	return nil
+}
+
+// promptMissingFields asks only for required fields that are still empty,
+// re-prompting until the input is valid. It returns false if input ended
+// before all of them were given.
+func promptMissingFields(reader *bufio.Reader, inst *Instance) bool {
+	prompts := []struct {
+		label    string
+		value    *string
+		validate func(string) (string, error)
+	}{
+		{"GCP Project ID: ", &inst.Project, func(s string) (string, error) { return s, validateProjectID(s) }},
+		{"Zone (e.g. us-central1-a): ", &inst.Zone, completeZone},
+		{"Instance Name: ", &inst.Name, func(s string) (string, error) { return s, validateInstanceName(s) }},
+	}
+	for _, p := range prompts {
+		for *p.value == "" {
+			fmt.Print("  " + p.label)
+			input, ok := readInput(reader)
+			if !ok {
+				fmt.Println()
+				return false
+			}
+			value, err := p.validate(input)
+			if err != nil {
+				fmt.Printf("  ✗ %v\n", err)
+				continue
+			}
+			*p.value = value
+		}
+	}
+	return true
+}
+
+// editCommand implements `gcp-ssh edit <alias> [--field value ...]`. Without
+// flags it prompts for every field when stdin is a terminal.
+func editCommand(config *Config, configPath string, args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	flags := newInstanceFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil || len(positional) != 1 {
+		return errUsage
+	}
+	alias := positional[0]
+
+	if fs.NFlag() == 0 {
+		if !isTerminal(os.Stdin) {
+			fmt.Println("  ✗ Nothing to change. Pass flags such as --zone or run in a terminal to edit interactively.")
+			return errFailed
+		}
+		return editInstance(config, configPath, alias)
+	}
+
+	i, ok := lookupAlias(config, alias)
+	if !ok {
+		printAliasNotFound(config, alias)
+		return errNotFound
+	}
+	inst := config.Instances[i]
+	flags.apply(fs, &inst)
+	if err := validateInstance(inst); err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		return errFailed
+	}
+	config.Instances[i] = inst
+	saveConfig(configPath, config)
+	fmt.Printf("  ✓ Instance '%s' updated.\n", inst.Alias)
+	return nil
+}
+
+// renameInstance changes the alias of a saved instance and rewrites the
+// connection history so recency ordering and `last` follow the new name.
+func renameInstance(config *Config, configPath string, oldAlias, newAlias string) error {
+	i, ok := lookupAlias(config, oldAlias)
+	if !ok {
+		printAliasNotFound(config, oldAlias)
+		return errNotFound
+	}
+	if err := validateAliasExcept(config, newAlias, i); err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		return errFailed
+	}
+	oldAlias = config.Instances[i].Alias
+	config.Instances[i].Alias = newAlias
+	saveConfig(configPath, config)
+	if err := renameHistoryAlias(oldAlias, newAlias); err != nil {
+		fmt.Printf("  ⚠ Could not update connection history: %v\n", err)
+	}
+	fmt.Printf("  ✓ Renamed '%s' to '%s'.\n", oldAlias, newAlias)
+	return nil
+}
+
+// cloneCommand implements `gcp-ssh clone <alias> <new-alias> [--field value ...]`,
+// copying every field of the saved instance and applying the given overrides.
+func cloneCommand(config *Config, configPath string, args []string) error {
+	fs := flag.NewFlagSet("clone", flag.ContinueOnError)
+	flags := newInstanceFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil || len(positional) != 2 {
+		return errUsage
+	}
+	i, ok := lookupAlias(config, positional[0])
+	if !ok {
+		printAliasNotFound(config, positional[0])
+		return errNotFound
+	}
+	newAlias := positional[1]
+	if err := validateAlias(config, newAlias); err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		return errFailed
+	}
+
+	inst := config.Instances[i]
+	inst.Alias = newAlias
+	inst.Tags = append([]string(nil), inst.Tags...)
+	flags.apply(fs, &inst)
+	if err := validateInstance(inst); err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		return errFailed
+	}
+	config.Instances = append(config.Instances, inst)
+	saveConfig(configPath, config)
+	fmt.Printf("  ✓ Cloned '%s' as '%s' (%s/%s/%s).\n", config.Instances[i].Alias, newAlias, inst.Project, inst.Zone, inst.Name)
+	return nil
+}
+
+// quickConnect implements `gcp-ssh quick <project> <zone> <name> [account]`,
+// connecting to an instance that is not saved.
+func quickConnect(config *Config, configPath string, args []string, mode string) error {
+	if len(args) < 3 || len(args) > 4 {
+		return errUsage
+	}
+	inst := Instance{Project: args[0], Zone: args[1], Name: args[2], ConnectionMode: mode}
+	if len(args) > 3 {
+		inst.GcloudAccount = args[3]
+	}
+	if err := validateTarget(inst); err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		return errUsage
+	}
+	return openByMode(config, configPath, inst, connectOptions{})
+}
+
+func editInstance(config *Config, configPath string, alias string) error {
+	i, ok := lookupAlias(config, alias)
+	if !ok {
+		printAliasNotFound(config, alias)
+		return errNotFound
+	}
+	alias = config.Instances[i].Alias
+	fmt.Printf("  Editing '%s' (Enter keeps the current value, '-' clears it)\n", alias)
+	config.Instances[i] = editInstanceDetails(bufio.NewReader(os.Stdin), config.Instances[i])
+	saveConfig(configPath, config)
+	fmt.Printf("  ✓ Instance '%s' updated.\n", alias)
+	return nil
+}
+
+func removeInstance(config *Config, configPath string, alias string) error {
+	i, ok := lookupAlias(config, alias)
+	if !ok {
+		printAliasNotFound(config, alias)
+		return errNotFound
+	}
+	alias = config.Instances[i].Alias
+	config.Instances = append(config.Instances[:i], config.Instances[i+1:]...)
+	saveConfig(configPath, config)
+	fmt.Printf("  ✓ Removed '%s'.\n", alias)
+	return nil
+}
+
+// listCommand implements `gcp-ssh list`, honoring --output.
+func listCommand(config *Config) error {
+	if !structuredOutput() {
+		listInstances(config)
+		return nil
+	}
+	lastConnected := loadLastConnected()
+	records := []instanceRecord{}
+	for _, inst := range sortedInstances(config) {
+		records = append(records, newInstanceRecord(inst, lastConnected))
+	}
+	return writeRecords(records)
+}
+
+// newInstanceRecord returns the row of `list` and `show` for inst.
+func newInstanceRecord(inst Instance, lastConnected map[string]time.Time) instanceRecord {
+	mode := inst.ConnectionMode
+	if mode == "" {
+		mode = "browser"
+	}
+	return instanceRecord{
+		Alias:          inst.Alias,
+		Project:        inst.Project,
+		Zone:           inst.Zone,
+		Name:           inst.Name,
+		AuthUser:       inst.AuthUser,
+		GcloudAccount:  inst.GcloudAccount,
+		ConnectionMode: mode,
+		AutoStop:       inst.AutoStop,
+		Tags:           append([]string{}, inst.Tags...),
+		LastConnected:  timeOrNil(lastConnected[sessionKey(inst)]),
+	}
+}
+
+// newShowRecord returns the output of `show` for inst.
+func newShowRecord(inst Instance, lastConnected map[string]time.Time) showRecord {
+	row := newInstanceRecord(inst, lastConnected)
+	record := showRecord{
+		Alias:          row.Alias,
+		Project:        row.Project,
+		Zone:           row.Zone,
+		Name:           row.Name,
+		AuthUser:       row.AuthUser,
+		GcloudAccount:  row.GcloudAccount,
+		ConnectionMode: row.ConnectionMode,
+		AutoStop:       row.AutoStop,
+		Tags:           row.Tags,
+		LastConnected:  row.LastConnected,
+		Session:        inst.Session,
+		RelocateZones:  append([]string{}, inst.RelocateZones...),
+		Profiles:       map[string]string{},
+	}
+	if inst.Retry != nil {
+		record.RetryAttempts = inst.Retry.Attempts
+		record.RetryInitialDelay = inst.Retry.InitialDelay
+		record.RetryMaxDelay = inst.Retry.MaxDelay
+	}
+	for name, profile := range inst.Profiles {
+		record.Profiles[name] = formatProfile(profile)
+	}
+	return record
+}
+
+// showCommand implements `gcp-ssh show <alias>`: every saved setting of one
+// instance, without asking gcloud about it.
+func showCommand(config *Config, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	inst, ok := resolveAlias(config, args[0])
+	if !ok {
+		return errNotFound
+	}
+	record := newShowRecord(inst, loadLastConnected())
+	if structuredOutput() {
+		return writeRecords([]showRecord{record})
+	}
+
+	orNone := func(s string) string {
+		if s == "" {
+			return "-"
 		}
+		return s
 	}
-	fmt.Printf("  ✗ Alias '%s' not found.\n", alias)
+	account := inst.GcloudAccount
+	if account == "" {
+		account = "(active gcloud account)"
+	}
+	lastUsed := "never"
+	if record.LastConnected != nil {
+		lastUsed = formatAge(time.Since(*record.LastConnected)) + " ago"
+	}
+	fmt.Printf("  ┌─ [%s]\n", inst.Alias)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  │  instance:\t%s/%s/%s\n", inst.Project, inst.Zone, inst.Name)
+	fmt.Fprintf(w, "  │  mode:\t%s (authuser=%d)\n", record.ConnectionMode, inst.AuthUser)
+	fmt.Fprintf(w, "  │  account:\t%s\n", account)
+	fmt.Fprintf(w, "  │  auto_stop:\t%s\n", orNone(inst.AutoStop))
+	fmt.Fprintf(w, "  │  session:\t%s\n", orNone(inst.Session))
+	fmt.Fprintf(w, "  │  tags:\t%s\n", orNone(strings.Join(inst.Tags, ",")))
+	fmt.Fprintf(w, "  │  relocate_zones:\t%s\n", orNone(strings.Join(inst.RelocateZones, ",")))
+	if inst.Retry != nil {
+		fmt.Fprintf(w, "  │  retry:\tattempts=%d, initial_delay=%s, max_delay=%s\n",
+			inst.Retry.Attempts, orNone(inst.Retry.InitialDelay), orNone(inst.Retry.MaxDelay))
+	}
+	for _, name := range profileNames(inst) {
+		fmt.Fprintf(w, "  │  profile %s:\t%s\n", name, record.Profiles[name])
+	}
+	fmt.Fprintf(w, "  │  last used:\t%s\n", lastUsed)
+	w.Flush()
+	fmt.Println("  └─")
+	return nil
 }
 
 func listInstances(config *Config) {
@@ -289,7 +774,7 @@ func listInstances(config *Config) {
 		return
 	}
 	fmt.Println("  ┌─ Saved Instances:")
-	for i, inst := range config.Instances {
+	for i, inst := range sortedInstances(config) {
 		mode := inst.ConnectionMode
 		if mode == "" {
 			mode = "browser"
@@ -298,35 +783,180 @@ func listInstances(config *Config) {
 		if account == "" {
 			account = "(active gcloud account)"
 		}
-		fmt.Printf("  │  %d) [%s] %s/%s/%s (authuser=%d, mode=%s, account=%s)\n",
-			i+1, inst.Alias, inst.Project, inst.Zone, inst.Name, inst.AuthUser, mode, account)
+		autoStop := ""
+		if inst.AutoStop != "" && inst.AutoStop != "never" {
+			autoStop = ", auto_stop=" + inst.AutoStop
+		}
+		tags := ""
+		if len(inst.Tags) > 0 {
+			tags = ", tags=" + strings.Join(inst.Tags, ",")
+		}
+		fmt.Printf("  │  %d) [%s] %s/%s/%s (authuser=%d, mode=%s, account=%s%s%s)\n",
+			i+1, inst.Alias, inst.Project, inst.Zone, inst.Name, inst.AuthUser, mode, account, autoStop, tags)
 	}
 	fmt.Println("  └─")
 }
 
-func connectByAlias(config *Config, alias string, forcedMode string) {
-	for _, inst := range config.Instances {
-		if inst.Alias == alias {
-			if forcedMode != "" {
-				inst.ConnectionMode = forcedMode
+// connectCommand implements `gcp-ssh connect[-terminal] <alias> [flags]`.
+func connectCommand(config *Config, configPath string, args []string, forcedMode string) error {
+	fs := flag.NewFlagSet("connect", flag.ContinueOnError)
+	machineType := fs.String("machine-type", "", "machine type to switch a stopped instance to")
+	profile := fs.String("profile", "", "saved profile to reshape a stopped instance to")
+	restore := fs.Bool("restore", false, "restore the original shape on auto-stop")
+	reconnect := fs.Bool("reconnect", false, "reopen the terminal session when the connection drops")
+	positional, err := parseFlags(fs, args)
+	if err != nil || len(positional) != 1 {
+		return errUsage
+	}
+	var shape *instanceShape
+	if *machineType != "" || *profile != "" {
+		shape = &instanceShape{Profile: *profile, MachineType: strings.ToLower(*machineType), Restore: *restore}
+		if *machineType != "" {
+			if err := validateMachineType(shape.MachineType); err != nil {
+				fmt.Printf("  ✗ %v\n", err)
+				return errUsage
 			}
-			openByMode(config, inst)
-			return
+		}
+	} else if *restore {
+		fmt.Println("  ✗ --restore only applies together with --machine-type or --profile.")
+		return errUsage
+	}
+	return connectByAlias(config, configPath, positional[0], forcedMode, connectOptions{Shape: shape, Reconnect: *reconnect})
+}
+
+// connectOptions are the per-connection flags of connect.
+type connectOptions struct {
+	Shape     *instanceShape
+	Reconnect bool
+}
+
+func connectByAlias(config *Config, configPath, alias string, forcedMode string, opts connectOptions) error {
+	inst, ok := resolveAlias(config, alias)
+	if !ok {
+		return errNotFound
+	}
+	if forcedMode != "" {
+		inst.ConnectionMode = forcedMode
+	}
+	if opts.Reconnect && inst.ConnectionMode != "terminal" {
+		fmt.Println("  ⚠ --reconnect only applies to terminal sessions; ignoring it.")
+	}
+	shape := opts.Shape
+	if shape != nil && shape.Profile != "" {
+		// Resolve the profile now that the instance is known; --machine-type
+		// overrides the profile's.
+		resolved, err := profileShape(inst, shape.Profile)
+		if err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			return errUsage
+		}
+		if shape.MachineType != "" {
+			resolved.MachineType = shape.MachineType
+		}
+		resolved.Restore = shape.Restore
+		shape = resolved
+	}
+	if shape != nil && shape.Restore {
+		// The original shape comes back only when auto-stop stops the instance.
+		if _, enabled, err := parseAutoStop(inst.AutoStop); err != nil || !enabled {
+			fmt.Printf("  ✗ --restore needs auto_stop on '%s', which has none. Set one with: gcp-ssh edit %s --auto-stop 30m\n", inst.Alias, inst.Alias)
+			return errUsage
 		}
 	}
-	fmt.Printf("  ✗ Alias '%s' not found. Use 'list' to see saved instances.\n", alias)
+	opts.Shape = shape
+	return openByMode(config, configPath, inst, opts)
 }
 
-func openByMode(config *Config, inst Instance) {
+// openByMode connects to inst in its preferred mode and returns the error
+// recorded in the history, if any. opts apply to this connection only.
+func openByMode(config *Config, configPath string, inst Instance, opts connectOptions) error {
 	mode := inst.ConnectionMode
 	if mode == "" {
 		mode = "browser"
 	}
+	ctx, stop := interruptContext()
+	defer stop()
+
+	start := time.Now()
+	inst, state, undo, readyErr := readyOrRelocate(ctx, config, configPath, inst, opts.Shape)
+	waited := time.Since(start)
+
+	if errors.Is(readyErr, errInterrupted) {
+		reportInterrupted(readyErr, inst)
+		recordHistory(inst, mode, start, waited, readyErr)
+		return readyErr
+	}
+
 	if mode == "terminal" {
-		connectTerminal(inst)
-		return
+		if readyErr != nil {
+			fmt.Println("  ✗ Cannot continue with terminal SSH until gcloud is available and the instance is running.")
+			recordHistory(inst, mode, start, waited, readyErr)
+			return readyErr
+		}
+		loop := sessionLoop{reconnect: opts.Reconnect}
+		var err error
+		for {
+			sessionStart := time.Now()
+			err = runTerminalSSH(ctx, inst, state.spot())
+			recordHistory(inst, mode, start, waited, err)
+			if !loop.again(ctx, inst, err, time.Since(sessionStart)) {
+				break
+			}
+			start = time.Now()
+			var reshaped *instanceShape
+			inst, state, reshaped, err = readyOrRelocate(ctx, config, configPath, inst, opts.Shape)
+			waited = time.Since(start)
+			if reshaped != nil {
+				undo = reshaped
+			}
+			if err != nil {
+				fmt.Println("  ✗ Cannot reconnect until the instance is running again.")
+				reportInterrupted(err, inst)
+				recordHistory(inst, mode, start, waited, err)
+				break
+			}
+		}
+		stop()
+		if !errors.Is(err, errPreempted) {
+			autoStopAfterSession(inst, pendingRestore(opts, undo))
+		}
+		return err
+	}
+
+	if readyErr != nil {
+		fmt.Println("  ⚠ Instance readiness could not be verified with gcloud. Please start it manually if needed.")
+	}
+	launchErr := openSSH(ctx, config, inst)
+	err := launchErr
+	if err == nil {
+		// The browser session itself is not observable; record only the launch.
+		err = readyErr
+	}
+	recordHistory(inst, mode, start, waited, err)
+	stop()
+	if launchErr == nil {
+		watchBrowserSession(inst, pendingRestore(opts, undo))
+	}
+	return err
+}
+
+// readyOrRelocate runs ensureShapedReady and, if the zone is out of
+// capacity, offers to move the instance and tries again in its new zone.
+func readyOrRelocate(ctx context.Context, config *Config, configPath string, inst Instance, shape *instanceShape) (Instance, instanceState, *instanceShape, error) {
+	state, undo, err := ensureShapedReady(ctx, inst, shape)
+	if capacityError(err) {
+		if moved, ok := relocateInstance(ctx, inst); ok {
+			saveRelocatedZone(config, configPath, inst, moved.Zone)
+			inst = moved
+			if dryRunSkip("check that '%s' is running in %s", inst.Name, inst.Zone) {
+				// The copy was not created, so there is nothing to describe.
+				return inst, instanceState{}, undo, nil
+			}
+			// The copy has the shape applied before the failed start.
+			state, _, err = ensureShapedReady(ctx, inst, shape)
+		}
 	}
-	openSSH(config, inst)
+	return inst, state, undo, err
 }
 
 // ─── Chrome profile ──────────────────────────────────────────────────────────
@@ -409,6 +1039,52 @@ func getProfileDisplayName(profilePath string) string {
 
 // ─── SSH URL & launch ────────────────────────────────────────────────────────
 
+// Connection errors. Readiness failures wrap errInstanceNotReady together with
+// their cause so callers can tell whether a session was started at all.
+var (
+	errInstanceNotReady = errors.New("instance not ready")
+	errGcloudMissing    = errors.New("gcloud CLI not found")
+	errAuthFailed       = errors.New("gcloud authentication failed")
+	errStatusFailed     = errors.New("could not read instance status")
+	errStartFailed      = errors.New("instance failed to start")
+	errSSHFailed        = errors.New("ssh session failed")
+	errBrowserFailed    = errors.New("could not open browser")
+)
+
+// readinessError wraps the failure of a readiness step. Expired credentials
+// count as an authentication failure whichever step ran into them.
+func readinessError(cause, err error) error {
+	if errors.Is(err, errReauthRequired) {
+		cause = errAuthFailed
+	}
+	return fmt.Errorf("%w: %w: %w", errInstanceNotReady, cause, err)
+}
+
+// errorCategory returns a short, stable name for the kind of failure, as used
+// in the history log and stats.
+func errorCategory(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, errGcloudMissing):
+		return "gcloud-missing"
+	case errors.Is(err, errAuthFailed):
+		return "auth"
+	case errors.Is(err, errStatusFailed):
+		return "status"
+	case errors.Is(err, errStartFailed):
+		return "start"
+	case errors.Is(err, errPreempted):
+		return "preempted"
+	case errors.Is(err, errSSHFailed):
+		return "ssh"
+	case errors.Is(err, errBrowserFailed):
+		return "browser"
+	default:
+		return "other"
+	}
+}
+
 func buildSSHURL(inst Instance) string {
 	return fmt.Sprintf(
 		"https://ssh.cloud.google.com/projects/%s/zones/%s/instances/%s?authuser=%d&hl=en_US&projectNumber=0",
@@ -416,18 +1092,21 @@ func buildSSHURL(inst Instance) string {
 	)
 }
 
-func openSSH(config *Config, inst Instance) {
-	if !ensureInstanceReady(inst) {
-		fmt.Println("  ⚠ Instance readiness could not be verified with gcloud. Please start it manually if needed.")
+// openSSH opens the browser SSH URL, preferring the configured Chrome profile.
+// Callers are expected to have run ensureInstanceReady.
+func openSSH(ctx context.Context, config *Config, inst Instance) error {
+	if err := contextError(ctx); err != nil {
+		return fmt.Errorf("%w: %w", errBrowserFailed, err)
 	}
-
 	url := buildSSHURL(inst)
 	chromePath := getChromeExecutable()
 
 	if chromePath == "" {
 		fmt.Println("  ✗ Could not find Chrome. Opening URL in default browser...")
-		openURLDefault(url)
-		return
+		if err := openURLDefault(url); err != nil {
+			return fmt.Errorf("%w: %w", errBrowserFailed, err)
+		}
+		return nil
 	}
 
 	profileDir := config.ChromeProfileDir
@@ -449,80 +1128,269 @@ func openSSH(config *Config, inst Instance) {
 	cmd := exec.Command(chromePath, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	if skipCommand(cmd) {
+		return nil
+	}
 
 	if err := cmd.Start(); err != nil {
+		logger.Warn("chrome launch failed", "cmd", commandLine(cmd), "err", err)
 		fmt.Printf("  ✗ Failed to launch Chrome: %v\n", err)
 		fmt.Println("  Trying default browser...")
-		openURLDefault(url)
-		return
+		if err := openURLDefault(url); err != nil {
+			return fmt.Errorf("%w: %w", errBrowserFailed, err)
+		}
+		return nil
 	}
 
 	fmt.Println("  ✓ Chrome launched! SSH session will authenticate automatically.")
+	return nil
 }
 
-func connectTerminal(inst Instance) {
-	if !ensureInstanceReady(inst) {
+// connectTerminal makes sure the instance is running and opens a terminal SSH
+// session. It returns an error wrapping errInstanceNotReady if no session was
+// started.
+func connectTerminal(ctx context.Context, inst Instance) error {
+	state, err := ensureInstanceReady(ctx, inst)
+	if err != nil {
 		fmt.Println("  ✗ Cannot continue with terminal SSH until gcloud is available and the instance is running.")
-		return
+		return err
 	}
+	err = runTerminalSSH(ctx, inst, state.spot())
+	printGcloudHint(err, inst)
+	return err
+}
 
+// runTerminalSSH runs gcloud compute ssh in the current terminal. The session
+// itself has no timeout and is not killed on cancellation: Ctrl-C reaches
+// the remote shell or ssh directly. Sessions on Spot VMs are watched for
+// preemption, which is reported as errPreempted. A session that ends with
+// the remote shell's own non-zero status is not a failure.
+func runTerminalSSH(ctx context.Context, inst Instance, spot bool) error {
+	if err := contextError(ctx); err != nil {
+		return fmt.Errorf("%w: %w", errSSHFailed, err)
+	}
 	fmt.Printf("  🚀 Opening terminal SSH for: %s (zone: %s, project: %s)\n", inst.Name, inst.Zone, inst.Project)
-	cmd := exec.Command("gcloud", "compute", "ssh", inst.Name, "--project", inst.Project, "--zone", inst.Zone)
+	args := []string{"compute", "ssh", inst.Name, "--project", inst.Project, "--zone", inst.Zone}
+	sessionArgs, err := sessionSSHArgs(inst)
+	if err != nil {
+		fmt.Printf("  ✗ %v. Fix it with: gcp-ssh edit %s --session ...\n", err, inst.Alias)
+		return fmt.Errorf("%w: %w", errSSHFailed, err)
+	}
+	args = append(args, sessionArgs...)
+	if spot {
+		// Notice a connection to a preempted VM within a minute instead of
+		// hanging on it.
+		args = append(args, "--ssh-flag=-o ServerAliveInterval=15", "--ssh-flag=-o ServerAliveCountMax=3")
+	}
+	cmd := exec.Command("gcloud", args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	stderr, tail := captureStderr(os.Stderr)
+	cmd.Stderr = stderr
+	if skipCommand(cmd) {
+		return nil
+	}
+	release := trackSession(inst)
+	defer release()
+	var watch *preemptionWatch
+	if spot {
+		watch = watchPreemption(inst)
+	}
+	err = finishCommand(ctx, cmd, cmd.Run(), tail.String())
+	if watch != nil && watch.stop(err) {
+		fmt.Printf("  ✗ The session ended because Google Cloud preempted '%s'.\n", inst.Name)
+		return fmt.Errorf("%w: %w", errSSHFailed, errPreempted)
+	}
+	if err != nil && !sessionFailed(err) {
+		logger.Info("session ended with the remote status", "name", inst.Name, "err", err)
+		return nil
+	}
+	if err != nil {
 		fmt.Printf("  ✗ gcloud compute ssh failed: %v\n", err)
+		return fmt.Errorf("%w: %w", errSSHFailed, err)
 	}
+	return nil
 }
 
-func ensureInstanceReady(inst Instance) bool {
+// ensureInstanceReady verifies gcloud and the account, then starts the
+// instance if it is not running. Errors wrap errInstanceNotReady and one of
+// the readiness causes below. The state returned is the one read before any
+// start.
+func ensureInstanceReady(ctx context.Context, inst Instance) (instanceState, error) {
+	state, _, err := ensureShapedReady(ctx, inst, nil)
+	return state, err
+}
+
+// ensureShapedReady is ensureInstanceReady that reshapes a stopped instance
+// to shape before starting it. It returns what undoes the reshaping, if
+// anything was changed.
+func ensureShapedReady(ctx context.Context, inst Instance, shape *instanceShape) (instanceState, *instanceShape, error) {
+	logger.Debug("checking instance", "alias", inst.Alias, "project", inst.Project, "zone", inst.Zone, "name", inst.Name)
 	if _, err := exec.LookPath("gcloud"); err != nil {
 		fmt.Println("  ⚠ gcloud CLI not found. Install gcloud or start the instance manually before SSH.")
-		return false
+		return instanceState{}, nil, fmt.Errorf("%w: %w", errInstanceNotReady, errGcloudMissing)
 	}
 
-	if !ensureGcloudAccount(inst.GcloudAccount) {
-		return false
+	if !ensureGcloudAccount(ctx, inst.GcloudAccount) {
+		if err := contextError(ctx); err != nil {
+			return instanceState{}, nil, readinessError(errAuthFailed, err)
+		}
+		return instanceState{}, nil, fmt.Errorf("%w: %w", errInstanceNotReady, errAuthFailed)
 	}
 
-	if err := runGcloudCommand("config", "set", "project", inst.Project); err != nil {
+	if err := setGcloudConfig(ctx, "project", inst.Project); err != nil {
 		fmt.Printf("  ✗ Failed to set active gcloud project: %v\n", err)
-		return false
+		printGcloudHint(err, inst)
+		return instanceState{}, nil, readinessError(errStatusFailed, err)
 	}
 
-	status, err := runGcloudValueCommand("compute", "instances", "describe", inst.Name,
-		"--project", inst.Project,
-		"--zone", inst.Zone,
-		"--format=value(status)")
+	var state instanceState
+	err := retryGcloud(ctx, inst, "Status check", func() error {
+		output, err := runGcloudValueCommand(ctx, "compute", "instances", "describe", inst.Name,
+			"--project", inst.Project,
+			"--zone", inst.Zone,
+			"--format="+instanceStateFormat)
+		state = parseInstanceState(output)
+		return err
+	})
 	if err != nil {
 		fmt.Printf("  ✗ Failed to read instance status: %v\n", err)
-		return false
+		printGcloudHint(err, inst)
+		return state, nil, readinessError(errStatusFailed, err)
 	}
 
+	status := state.Status
+	logger.Debug("instance status", "name", inst.Name, "status", status, "provisioning", state.ProvisioningModel, "preemptible", state.Preemptible)
+	warnSpot(inst, state)
 	if strings.EqualFold(status, "RUNNING") {
 		fmt.Println("  ✓ Instance is already running.")
-		return true
+		if shape != nil {
+			fmt.Println("  ⚠ Not reshaping: a running instance keeps its machine type and GPUs. Stop it first, or use 'gcp-ssh resize --stop'.")
+		}
+		return state, nil, nil
+	}
+
+	undo, err := applyShape(ctx, inst, shape)
+	if err != nil {
+		return state, undo, readinessError(errStartFailed, err)
 	}
 
 	fmt.Printf("  ℹ Instance status is '%s'. Starting instance...\n", status)
-	if err := runGcloudCommand("compute", "instances", "start", inst.Name,
-		"--project", inst.Project,
-		"--zone", inst.Zone); err != nil {
+	err = retryGcloud(ctx, inst, "Start", func() error {
+		ctx, cancel := context.WithTimeout(ctx, gcloudStartTimeout)
+		defer cancel()
+		return runGcloudCommand(ctx, "compute", "instances", "start", inst.Name,
+			"--project", inst.Project,
+			"--zone", inst.Zone)
+	})
+	if err != nil {
 		fmt.Printf("  ✗ Failed to start instance: %v\n", err)
-		return false
+		printGcloudHint(err, inst)
+		return state, undo, readinessError(errStartFailed, err)
+	}
+	if options.dryRun {
+		return state, undo, nil
 	}
 
+	logger.Info("instance started", "name", inst.Name)
 	fmt.Println("  ✓ Instance started.")
+	return state, undo, nil
+}
+
+// instanceDetails is the subset of `gcloud compute instances describe` output
+// used by the tool.
+type instanceDetails struct {
+	Name               string            `json:"name"`
+	Zone               string            `json:"zone"` // full resource URL
+	Status             string            `json:"status"`
+	MachineType        string            `json:"machineType"` // full resource URL
+	LastStartTimestamp string            `json:"lastStartTimestamp"`
+	Labels             map[string]string `json:"labels"`
+	GuestAccelerators  []struct {
+		AcceleratorType  string `json:"acceleratorType"` // full resource URL
+		AcceleratorCount int    `json:"acceleratorCount"`
+	} `json:"guestAccelerators"`
+	Scheduling struct {
+		OnHostMaintenance string `json:"onHostMaintenance"`
+	} `json:"scheduling"`
+	NetworkInterfaces []struct {
+		NetworkIP     string `json:"networkIP"`
+		AccessConfigs []struct {
+			NatIP string `json:"natIP"`
+		} `json:"accessConfigs"`
+	} `json:"networkInterfaces"`
+}
+
+// internalIP returns the primary internal IP address, if any.
+func (d instanceDetails) internalIP() string {
+	if len(d.NetworkInterfaces) == 0 {
+		return ""
+	}
+	return d.NetworkInterfaces[0].NetworkIP
+}
+
+// externalIP returns the primary external IP address, if any.
+func (d instanceDetails) externalIP() string {
+	for _, nic := range d.NetworkInterfaces {
+		for _, ac := range nic.AccessConfigs {
+			if ac.NatIP != "" {
+				return ac.NatIP
+			}
+		}
+	}
+	return ""
+}
+
+// describeInstance fetches instance details without changing the active
+// gcloud account.
+func describeInstance(ctx context.Context, inst Instance) (instanceDetails, error) {
+	var details instanceDetails
+	args := []string{"compute", "instances", "describe", inst.Name,
+		"--project", inst.Project,
+		"--zone", inst.Zone,
+		"--format=json"}
+	if inst.GcloudAccount != "" {
+		args = append(args, "--account", inst.GcloudAccount)
+	}
+	output, err := runGcloudValueCommand(ctx, args...)
+	if err != nil {
+		return details, err
+	}
+	err = json.Unmarshal([]byte(output), &details)
+	return details, err
+}
+
+// lastStart returns when the instance was last started, or the zero time.
+func (d instanceDetails) lastStart() time.Time {
+	t, _ := time.Parse(time.RFC3339, d.LastStartTimestamp)
+	return t
+}
+
+func stopInstance(ctx context.Context, inst Instance) bool {
+	fmt.Printf("  ℹ Stopping instance '%s'...\n", inst.Name)
+	ctx, cancel := context.WithTimeout(ctx, gcloudStopTimeout)
+	defer cancel()
+	if err := runGcloudCommand(ctx, withAccount(inst, "compute", "instances", "stop", inst.Name,
+		"--project", inst.Project,
+		"--zone", inst.Zone)...); err != nil {
+		fmt.Printf("  ✗ Failed to stop instance: %v\n", err)
+		return false
+	}
+	if options.dryRun {
+		return true
+	}
+	fmt.Println("  ✓ Instance stopped.")
 	return true
 }
 
-func ensureGcloudAccount(requiredAccount string) bool {
-	active, err := runGcloudValueCommand("auth", "list", "--filter=status:ACTIVE", "--format=value(account)")
+func ensureGcloudAccount(ctx context.Context, requiredAccount string) bool {
+	active, err := runGcloudValueCommand(ctx, "auth", "list", "--filter=status:ACTIVE", "--format=value(account)")
 	if err != nil {
 		fmt.Printf("  ✗ Failed to determine active gcloud account: %v\n", err)
+		printGcloudHint(err, Instance{GcloudAccount: requiredAccount})
 		return false
 	}
+	logger.Debug("gcloud account", "active", active, "required", requiredAccount)
 
 	if requiredAccount == "" {
 		if active == "" {
@@ -539,12 +1407,12 @@ func ensureGcloudAccount(requiredAccount string) bool {
 	}
 
 	fmt.Printf("  ℹ Active gcloud account is '%s', but '%s' is required.\n", active, requiredAccount)
-	accounts, err := runGcloudValueCommand("auth", "list", "--format=value(account)")
+	accounts, err := runGcloudValueCommand(ctx, "auth", "list", "--format=value(account)")
 	if err == nil {
 		for _, account := range strings.Split(accounts, "\n") {
 			if strings.TrimSpace(account) == requiredAccount {
 				fmt.Printf("  ℹ Switching gcloud account to '%s'...\n", requiredAccount)
-				if err := runGcloudCommand("config", "set", "account", requiredAccount); err != nil {
+				if err := setGcloudConfig(ctx, "account", requiredAccount); err != nil {
 					fmt.Printf("  ✗ Failed to set gcloud account: %v\n", err)
 					return false
 				}
@@ -554,29 +1422,50 @@ func ensureGcloudAccount(requiredAccount string) bool {
 	}
 
 	fmt.Printf("  ℹ Logging in to gcloud as '%s'...\n", requiredAccount)
-	if err := runGcloudCommand("auth", "login", requiredAccount); err != nil {
+	// Login waits for the user in the browser, so it has no timeout.
+	if err := runGcloudCommand(ctx, "auth", "login", requiredAccount); err != nil {
 		fmt.Printf("  ✗ gcloud login failed for '%s': %v\n", requiredAccount, err)
 		return false
 	}
-	if err := runGcloudCommand("config", "set", "account", requiredAccount); err != nil {
+	if err := setGcloudConfig(ctx, "account", requiredAccount); err != nil {
 		fmt.Printf("  ✗ Failed to set gcloud account after login: %v\n", err)
 		return false
 	}
 	return true
 }
 
-func runGcloudCommand(args ...string) error {
-	cmd := exec.Command("gcloud", args...)
+// runGcloudCommand runs gcloud attached to the terminal. Callers bound it
+// with a timeout where the step has one.
+func runGcloudCommand(ctx context.Context, args ...string) error {
+	cmd := gcloudCommand(ctx, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	stderr, tail := captureStderr(os.Stderr)
+	cmd.Stderr = stderr
+	if skipCommand(cmd) {
+		return nil
+	}
+	return finishCommand(ctx, cmd, cmd.Run(), tail.String())
 }
 
-func runGcloudValueCommand(args ...string) (string, error) {
-	cmd := exec.Command("gcloud", args...)
+// setGcloudConfig sets a gcloud property, bounded by gcloudQueryTimeout.
+func setGcloudConfig(ctx context.Context, property, value string) error {
+	ctx, cancel := context.WithTimeout(ctx, gcloudQueryTimeout)
+	defer cancel()
+	return runGcloudCommand(ctx, "config", "set", property, value)
+}
+
+// runGcloudValueCommand runs a read-only gcloud query, bounded by
+// gcloudQueryTimeout, and returns its trimmed output.
+func runGcloudValueCommand(ctx context.Context, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gcloudQueryTimeout)
+	defer cancel()
+	cmd := gcloudCommand(ctx, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	traceCommand(cmd)
 	output, err := cmd.Output()
-	if err != nil {
+	if err := finishCommand(ctx, cmd, err, stderr.String()); err != nil {
 		return "", err
 	}
 	return strings.TrimSpace(string(output)), nil
@@ -631,7 +1520,7 @@ func getChromeUserDataDir() string {
 	return ""
 }
 
-func openURLDefault(url string) {
+func openURLDefault(url string) error {
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
 	case "darwin":
@@ -641,9 +1530,18 @@ func openURLDefault(url string) {
 	case "linux":
 		cmd = exec.Command("xdg-open", url)
 	}
-	if cmd != nil {
-		cmd.Start()
+	if cmd == nil {
+		return fmt.Errorf("no default browser launcher for %s", runtime.GOOS)
+	}
+	if skipCommand(cmd) {
+		return nil
+	}
+	if err := cmd.Start(); err != nil {
+		logger.Warn("default browser launch failed", "cmd", commandLine(cmd), "err", err)
+		fmt.Printf("  ✗ Failed to open default browser: %v\n", err)
+		return err
 	}
+	return nil
 }
 
 // ─── Utilities ───────────────────────────────────────────────────────────────
@@ -653,29 +1551,38 @@ func readLine(reader *bufio.Reader) string {
 	return strings.TrimSpace(line)
 }
 
-func printHelp() {
-	fmt.Println(`
-GCP SSH Launcher (Browser + Terminal)
-=====================================
-
-Usage:
-  gcp-ssh                                   Interactive mode
-  gcp-ssh <alias>                           Connect to saved instance by alias
-  gcp-ssh connect <alias>                   Connect to saved instance by alias
-  gcp-ssh connect-terminal <alias>          Force terminal SSH mode for alias
-  gcp-ssh quick <project> <zone> <vm> [acc] One-off quick connect in browser mode
-  gcp-ssh quick-terminal <project> <zone> <vm> [acc]
-                                            One-off quick connect in terminal mode
-  gcp-ssh add                               Add a new saved instance
-  gcp-ssh list                              List saved instances
-  gcp-ssh remove <alias>                    Remove a saved instance
-  gcp-ssh profile                           Change Chrome profile
-  gcp-ssh help                              Show this help
-
-Before SSH, the tool now:
-  1) verifies gcloud CLI is installed,
-  2) verifies/sets the expected gcloud account,
-  3) checks and starts the instance if not running.
-
-Config is stored at: ~/.gcp-ssh/config.json`)
+// readInput is like readLine but also reports false once input has ended, so
+// prompt loops can stop asking.
+func readInput(reader *bufio.Reader) (string, bool) {
+	line, err := reader.ReadString('\n')
+	return strings.TrimSpace(line), err == nil || line != ""
+}
+
+// parseTags splits a comma separated list into trimmed, non-empty tags.
+func parseTags(input string) []string {
+	var tags []string
+	for _, tag := range strings.Split(input, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// parseFlags parses flags that may appear before, between or after positional
+// arguments and returns the positional arguments in order.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
 }
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// Listing commands print human-oriented text by default. With --output they
+// print rows of one of the record types below instead; the JSON keys of each
+// type are its stable, documented schema and are also the column names of
+// the table and tsv formats.
+
+// outputFormats are the values accepted by --output, besides template=...
+var outputFormats = []string{"text", "table", "tsv", "json", "yaml"}
+
+// templatePrefix introduces a Go text/template, executed once per row.
+const templatePrefix = "template="
+
+// validateOutput checks a --output value.
+func validateOutput(format string) error {
+	if tmpl, ok := strings.CutPrefix(format, templatePrefix); ok {
+		_, err := parseRowTemplate(tmpl)
+		return err
+	}
+	if !contains(outputFormats, format) {
+		return fmt.Errorf("invalid --output '%s' (use one of: %s, or template=...)", format, strings.Join(outputFormats, ", "))
+	}
+	return nil
+}
+
+// structuredOutput reports whether a listing command should print records
+// instead of its text view.
+func structuredOutput() bool {
+	return options.output != "text"
+}
+
+// ─── Record types ────────────────────────────────────────────────────────────
+
+// instanceRecord is a row of `list`.
+type instanceRecord struct {
+	Alias          string     `json:"alias"`
+	Project        string     `json:"project"`
+	Zone           string     `json:"zone"`
+	Name           string     `json:"name"`
+	AuthUser       int        `json:"authuser"`
+	GcloudAccount  string     `json:"gcloud_account"`
+	ConnectionMode string     `json:"connection_mode"`
+	AutoStop       string     `json:"auto_stop"`
+	Tags           []string   `json:"tags"`
+	LastConnected  *time.Time `json:"last_connected"`
+}
+
+// showRecord is the output of `show`: the columns of instanceRecord, in the
+// same order, plus the settings `list` leaves out. Unset retry fields are
+// empty or 0, meaning the defaults.
+type showRecord struct {
+	Alias             string            `json:"alias"`
+	Project           string            `json:"project"`
+	Zone              string            `json:"zone"`
+	Name              string            `json:"name"`
+	AuthUser          int               `json:"authuser"`
+	GcloudAccount     string            `json:"gcloud_account"`
+	ConnectionMode    string            `json:"connection_mode"`
+	AutoStop          string            `json:"auto_stop"`
+	Tags              []string          `json:"tags"`
+	LastConnected     *time.Time        `json:"last_connected"`
+	Session           string            `json:"session"`
+	RelocateZones     []string          `json:"relocate_zones"`
+	RetryAttempts     int               `json:"retry_attempts"`
+	RetryInitialDelay string            `json:"retry_initial_delay"`
+	RetryMaxDelay     string            `json:"retry_max_delay"`
+	Profiles          map[string]string `json:"profiles"` // name → shape, as formatProfile renders it
+}
+
+// activityRecord is a row of `idle-report`.
+type activityRecord struct {
+	Alias          string     `json:"alias"`
+	Project        string     `json:"project"`
+	Zone           string     `json:"zone"`
+	Name           string     `json:"name"`
+	Status         string     `json:"status"`
+	UptimeSeconds  float64    `json:"uptime_seconds"`
+	LastStart      *time.Time `json:"last_start"`
+	LastConnected  *time.Time `json:"last_connected"`
+	ActiveSessions int        `json:"active_sessions"` // gcp-ssh sessions on this machine
+	Idle           bool       `json:"idle"`
+	Error          string     `json:"error"`
+}
+
+// statsRecord is a row of `stats`, one per alias and one per project.
+type statsRecord struct {
+	Group                string         `json:"group"` // alias or project
+	Key                  string         `json:"key"`
+	Sessions             int            `json:"sessions"`
+	Failures             map[string]int `json:"failures"`
+	TotalSessionSeconds  float64        `json:"total_session_seconds"`
+	MedianSessionSeconds float64        `json:"median_session_seconds"`
+	MedianWaitSeconds    float64        `json:"median_wait_seconds"`
+	MaxWaitSeconds       float64        `json:"max_wait_seconds"`
+}
+
+// sessionRecord is a row of `sessions`.
+type sessionRecord struct {
+	Alias    string     `json:"alias"`
+	Tool     string     `json:"tool"` // tmux or screen
+	Name     string     `json:"name"`
+	Windows  int        `json:"windows"` // 0 for screen, which does not report it
+	Attached bool       `json:"attached"`
+	Created  *time.Time `json:"created"`
+}
+
+// zoneRecord is a row of `zones`.
+type zoneRecord struct {
+	Zone   string `json:"zone"`
+	Region string `json:"region"`
+}
+
+// timeOrNil returns nil for the zero time, so it is encoded as null, and
+// drops sub-second precision.
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.Truncate(time.Second)
+	return &t
+}
+
+// ─── Writers ─────────────────────────────────────────────────────────────────
+
+// writeRecords prints rows, a slice of one of the record types, in the
+// --output format.
+func writeRecords(rows any) error {
+	return writeRecordsTo(os.Stdout, options.output, rows)
+}
+
+func writeRecordsTo(w io.Writer, format string, rows any) error {
+	list := reflect.ValueOf(rows)
+	columns := recordColumns(list.Type().Elem())
+
+	if tmpl, ok := strings.CutPrefix(format, templatePrefix); ok {
+		t, err := parseRowTemplate(tmpl)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < list.Len(); i++ {
+			var b strings.Builder
+			if err := t.Execute(&b, list.Index(i).Interface()); err != nil {
+				return err
+			}
+			out := b.String()
+			if !strings.HasSuffix(out, "\n") {
+				out += "\n"
+			}
+			io.WriteString(w, out)
+		}
+		return nil
+	}
+
+	switch format {
+	case "json":
+		if list.Len() == 0 {
+			rows = []struct{}{} // print [] rather than null
+		}
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	case "yaml":
+		if list.Len() == 0 {
+			fmt.Fprintln(w, "[]")
+		}
+		for i := 0; i < list.Len(); i++ {
+			row := list.Index(i)
+			for j, col := range columns {
+				prefix := "  "
+				if j == 0 {
+					prefix = "- "
+				}
+				writeYAMLField(w, prefix, col.name, row.Field(col.index))
+			}
+		}
+	case "tsv":
+		header := make([]string, len(columns))
+		for j, col := range columns {
+			header[j] = col.name
+		}
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+		for i := 0; i < list.Len(); i++ {
+			cells := make([]string, len(columns))
+			for j, col := range columns {
+				cells[j] = strings.NewReplacer("\t", " ", "\n", " ").Replace(cellText(list.Index(i).Field(col.index)))
+			}
+			fmt.Fprintln(w, strings.Join(cells, "\t"))
+		}
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		header := make([]string, len(columns))
+		for j, col := range columns {
+			header[j] = strings.ToUpper(col.name)
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for i := 0; i < list.Len(); i++ {
+			cells := make([]string, len(columns))
+			for j, col := range columns {
+				if cells[j] = cellText(list.Index(i).Field(col.index)); cells[j] == "" {
+					cells[j] = "-"
+				}
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format '%s'", format)
+	}
+	return nil
+}
+
+func parseRowTemplate(text string) (*template.Template, error) {
+	t, err := template.New("output").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output template: %w", err)
+	}
+	return t, nil
+}
+
+type recordColumn struct {
+	name  string
+	index int
+}
+
+// recordColumns returns the JSON names of the fields of a record type.
+func recordColumns(t reflect.Type) []recordColumn {
+	var columns []recordColumn
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			columns = append(columns, recordColumn{name, i})
+		}
+	}
+	return columns
+}
+
+// cellText renders a field for the table and tsv formats.
+func cellText(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return ""
+		}
+		return cellText(v.Elem())
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = cellText(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+	case reflect.Map:
+		var parts []string
+		for _, key := range sortedMapKeys(v) {
+			parts = append(parts, fmt.Sprintf("%s=%s", cellText(key), cellText(v.MapIndex(key))))
+		}
+		return strings.Join(parts, ",")
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// writeYAMLField writes "key: value" in block style.
+func writeYAMLField(w io.Writer, prefix, key string, v reflect.Value) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			fmt.Fprintf(w, "%s%s: null\n", prefix, key)
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Len() == 0 {
+			fmt.Fprintf(w, "%s%s: []\n", prefix, key)
+			return
+		}
+		fmt.Fprintf(w, "%s%s:\n", prefix, key)
+		for i := 0; i < v.Len(); i++ {
+			fmt.Fprintf(w, "    - %s\n", yamlScalar(v.Index(i)))
+		}
+	case reflect.Map:
+		if v.Len() == 0 {
+			fmt.Fprintf(w, "%s%s: {}\n", prefix, key)
+			return
+		}
+		fmt.Fprintf(w, "%s%s:\n", prefix, key)
+		for _, k := range sortedMapKeys(v) {
+			fmt.Fprintf(w, "    %s: %s\n", yamlScalar(k), yamlScalar(v.MapIndex(k)))
+		}
+	default:
+		fmt.Fprintf(w, "%s%s: %s\n", prefix, key, yamlScalar(v))
+	}
+}
+
+// yamlPlain matches strings that YAML reads back as the same plain string.
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_/.][A-Za-z0-9_./@+ -]*[A-Za-z0-9_./@+-]$|^[A-Za-z_]$`)
+
+func yamlScalar(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		switch strings.ToLower(s) {
+		case "true", "false", "yes", "no", "on", "off", "null", "y", "n":
+			return strconv.Quote(s)
+		}
+		if yamlPlain.MatchString(s) {
+			return s
+		}
+		return strconv.Quote(s)
+	case reflect.Bool, reflect.Int, reflect.Float64:
+		return cellText(v)
+	}
+	return strconv.Quote(cellText(v))
+}
+
+func sortedMapKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return cellText(keys[i]) < cellText(keys[j]) })
+	return keys
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteRecordsToYAML(t *testing.T) {
+	connected := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		rows any
+		want string
+	}{
+		{
+			name: "empty",
+			rows: []instanceRecord{},
+			want: "[]\n",
+		},
+		{
+			name: "instances",
+			rows: []instanceRecord{
+				{Alias: "dev", Project: "my-project", Zone: "us-central1-a", Name: "dev", GcloudAccount: "me@example.com",
+					ConnectionMode: "terminal", AutoStop: "30", Tags: []string{"gpu", "team a"}, LastConnected: &connected},
+				{Alias: "yes", Project: "p", Zone: "z", Name: "n", ConnectionMode: "browser", AuthUser: 1},
+			},
+			want: `- alias: dev
+  project: my-project
+  zone: us-central1-a
+  name: dev
+  authuser: 0
+  gcloud_account: me@example.com
+  connection_mode: terminal
+  auto_stop: "30"
+  tags:
+    - gpu
+    - team a
+  last_connected: "2024-05-01T10:30:00Z"
+- alias: "yes"
+  project: p
+  zone: z
+  name: "n"
+  authuser: 1
+  gcloud_account: ""
+  connection_mode: browser
+  auto_stop: ""
+  tags: []
+  last_connected: null
+`,
+		},
+		{
+			name: "stats with failures",
+			rows: []statsRecord{
+				{Group: "alias", Key: "dev", Sessions: 3, Failures: map[string]int{"ssh": 1, "auth": 2}, TotalSessionSeconds: 90.5},
+				{Group: "project", Key: "my-project", Failures: map[string]int{}},
+			},
+			want: `- group: alias
+  key: dev
+  sessions: 3
+  failures:
+    auth: 2
+    ssh: 1
+  total_session_seconds: 90.5
+  median_session_seconds: 0
+  median_wait_seconds: 0
+  max_wait_seconds: 0
+- group: project
+  key: my-project
+  sessions: 0
+  failures: {}
+  total_session_seconds: 0
+  median_session_seconds: 0
+  median_wait_seconds: 0
+  max_wait_seconds: 0
+`,
+		},
+		{
+			name: "quoting",
+			rows: []zoneRecord{{Zone: "a: b", Region: "#x"}, {Zone: "say \"hi\"", Region: "off"}},
+			want: `- zone: "a: b"
+  region: "#x"
+- zone: "say \"hi\""
+  region: "off"
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := writeRecordsTo(&b, "yaml", tt.rows); err != nil {
+				t.Fatalf("writeRecordsTo() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("writeRecordsTo() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRecordsToFormats(t *testing.T) {
+	rows := []zoneRecord{{Zone: "us-central1-a", Region: "us-central1"}, {Zone: "europe-west1-b"}}
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{format: "tsv", want: "zone\tregion\nus-central1-a\tus-central1\neurope-west1-b\t\n"},
+		{format: "table", want: "ZONE            REGION\nus-central1-a   us-central1\neurope-west1-b  -\n"},
+		{format: "template={{.Zone}} in {{.Region}}", want: "us-central1-a in us-central1\neurope-west1-b in \n"},
+		{format: "template={{.Missing}}", wantErr: true},
+		{format: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var b strings.Builder
+			err := writeRecordsTo(&b, tt.format, rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeRecordsTo(%q) error = %v, want error %v", tt.format, err, tt.wantErr)
+			}
+			if !tt.wantErr && b.String() != tt.want {
+				t.Errorf("writeRecordsTo(%q) = %q, want %q", tt.format, b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestShowRecordOutput(t *testing.T) {
+	inst := Instance{
+		Alias: "train", Project: "ml-project", Zone: "us-central1-a", Name: "trainer",
+		ConnectionMode: "terminal", AutoStop: "30m", Session: "tmux:work",
+		RelocateZones: []string{"us-central1-b", "us-east1-c"},
+		Retry:         &RetryPolicy{Attempts: 6, InitialDelay: "10s"},
+		Profiles: map[string]InstanceProfile{
+			"gpu": {MachineType: "n1-standard-8", Accelerators: []Accelerator{{Type: "nvidia-tesla-t4", Count: 1}}},
+			"cpu": {},
+		},
+	}
+	record := newShowRecord(inst, nil)
+
+	var yaml strings.Builder
+	if err := writeRecordsTo(&yaml, "yaml", []showRecord{record}); err != nil {
+		t.Fatalf("writeRecordsTo(yaml) error = %v", err)
+	}
+	wantYAML := `- alias: train
+  project: ml-project
+  zone: us-central1-a
+  name: trainer
+  authuser: 0
+  gcloud_account: ""
+  connection_mode: terminal
+  auto_stop: "30m"
+  tags: []
+  last_connected: null
+  session: "tmux:work"
+  relocate_zones:
+    - us-central1-b
+    - us-east1-c
+  retry_attempts: 6
+  retry_initial_delay: "10s"
+  retry_max_delay: ""
+  profiles:
+    cpu: no GPUs
+    gpu: n1-standard-8 + 1 x nvidia-tesla-t4
+`
+	if yaml.String() != wantYAML {
+		t.Errorf("yaml =\n%s\nwant\n%s", yaml.String(), wantYAML)
+	}
+
+	// Without retry settings or profiles the keys are still there, so
+	// scripts can rely on them.
+	var b strings.Builder
+	if err := writeRecordsTo(&b, "json", []showRecord{newShowRecord(Instance{Alias: "dev"}, nil)}); err != nil {
+		t.Fatalf("writeRecordsTo(json) error = %v", err)
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(b.String()), &rows); err != nil || len(rows) != 1 {
+		t.Fatalf("json output %q: %v", b.String(), err)
+	}
+	for key, want := range map[string]any{
+		"connection_mode":     "browser",
+		"session":             "",
+		"relocate_zones":      []any{},
+		"retry_attempts":      0.0,
+		"retry_initial_delay": "",
+		"profiles":            map[string]any{},
+	} {
+		if got, ok := rows[0][key]; !ok || !reflect.DeepEqual(got, want) {
+			t.Errorf("json %s = %#v (present %v), want %#v", key, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// detailsUpdate carries freshly fetched instance details to a live view.
+type detailsUpdate struct {
+	Key     string // sessionKey of the instance
+	Details instanceDetails
+	Err     error
+}
+
+// status returns the instance status, or UNKNOWN if it could not be read.
+func (u detailsUpdate) status() string {
+	if u.Err != nil || u.Details.Status == "" {
+		return "UNKNOWN"
+	}
+	return u.Details.Status
+}
+
+// maxDescribeCalls bounds the number of concurrent gcloud describe calls.
+const maxDescribeCalls = 4
+
+// fetchDetails describes every instance in the background and sends the
+// results as they arrive. The channel is buffered so abandoned fetches never
+// block.
+func fetchDetails(instances []Instance) <-chan detailsUpdate {
+	updates := make(chan detailsUpdate, len(instances))
+	sem := make(chan struct{}, maxDescribeCalls)
+	var wg sync.WaitGroup
+	for _, inst := range instances {
+		wg.Add(1)
+		go func(inst Instance) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			details, err := describeInstance(context.Background(), inst)
+			updates <- detailsUpdate{Key: sessionKey(inst), Details: details, Err: err}
+		}(inst)
+	}
+	go func() {
+		wg.Wait()
+		close(updates)
+	}()
+	return updates
+}
+
+// fuzzyScore returns how well query matches text as an in-order subsequence,
+// or -1 if it does not match. Consecutive characters and matches at the start
+// of words score higher.
+func fuzzyScore(query, text string) int {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+	if len(q) == 0 {
+		return 0
+	}
+	score, qi, prev := 0, 0, -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if ti == prev+1 {
+			score += 2
+		}
+		if ti == 0 || strings.ContainsRune(" -_/.", t[ti-1]) {
+			score += 3
+		}
+		prev = ti
+		qi++
+	}
+	if qi < len(q) {
+		return -1
+	}
+	return score
+}
+
+// searchText is what the picker matches a query against.
+func searchText(inst Instance) string {
+	return strings.Join(append([]string{inst.Alias, inst.Project, inst.Zone, inst.Name}, inst.Tags...), " ")
+}
+
+// filterInstances returns the instances matching query, best matches first.
+// Ties keep the incoming (most-recently-used) order.
+func filterInstances(instances []Instance, query string) []Instance {
+	type scored struct {
+		inst  Instance
+		score int
+	}
+	var matches []scored
+	for _, inst := range instances {
+		score := fuzzyScore(query, searchText(inst))
+		if score < 0 {
+			continue
+		}
+		if query != "" && strings.HasPrefix(strings.ToLower(inst.Alias), strings.ToLower(query)) {
+			score += 10
+		}
+		matches = append(matches, scored{inst, score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	result := make([]Instance, len(matches))
+	for i, m := range matches {
+		result[i] = m.inst
+	}
+	return result
+}
+
+// pickInstance shows a full-screen fuzzy finder over instances, starting with
+// the given query. It returns false if the user cancelled or the terminal
+// could not be switched to raw mode.
+func pickInstance(instances []Instance, query string) (Instance, bool) {
+	restore, err := enableRawMode()
+	if err != nil {
+		return Instance{}, false
+	}
+	defer restore()
+	fmt.Print(ansiAltScreen + ansiHideCursor)
+	defer fmt.Print(ansiShowCursor + ansiMainScreen)
+
+	statuses := map[string]string{}
+	updates := fetchDetails(instances)
+	selected := 0
+	dirty := true
+
+	for {
+		matches := filterInstances(instances, query)
+		if selected >= len(matches) {
+			selected = max(len(matches)-1, 0)
+		}
+		if dirty {
+			renderPicker(matches, statuses, query, selected, len(instances))
+			dirty = false
+		}
+
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				updates = nil
+				continue
+			}
+			statuses[update.Key] = update.status()
+			dirty = true
+			continue
+		default:
+		}
+
+		key, text := readKey()
+		switch key {
+		case keyNone:
+			continue
+		case keyEnter:
+			if len(matches) > 0 {
+				return matches[selected], true
+			}
+		case keyEscape, keyInterrupt:
+			return Instance{}, false
+		case keyUp:
+			if selected > 0 {
+				selected--
+			}
+		case keyDown:
+			if selected < len(matches)-1 {
+				selected++
+			}
+		case keyBackspace:
+			if q := []rune(query); len(q) > 0 {
+				query = string(q[:len(q)-1])
+				selected = 0
+			}
+		case keyClearLine:
+			query, selected = "", 0
+		case keyRune:
+			query += text
+			selected = 0
+		}
+		dirty = true
+	}
+}
+
+func renderPicker(matches []Instance, statuses map[string]string, query string, selected, total int) {
+	rows, cols := terminalSize()
+	var b strings.Builder
+	b.WriteString(ansiClear)
+	fmt.Fprintf(&b, "  🔎 %s▏\r\n", query)
+	fmt.Fprintf(&b, "%s  %d/%d  ↑/↓ select · Enter connect · Esc cancel%s\r\n", ansiDim, len(matches), total, ansiReset)
+
+	// Keep the selection visible when the list is taller than the screen.
+	visible := max(rows-3, 1)
+	offset := 0
+	if selected >= visible {
+		offset = selected - visible + 1
+	}
+	for i := offset; i < len(matches) && i < offset+visible; i++ {
+		inst := matches[i]
+		status, ok := statuses[sessionKey(inst)]
+		if !ok {
+			status = "…"
+		}
+		tags := ""
+		if len(inst.Tags) > 0 {
+			tags = " #" + strings.Join(inst.Tags, " #")
+		}
+		line := fmt.Sprintf("%-12s %-10s %s/%s/%s%s", inst.Alias, status, inst.Project, inst.Zone, inst.Name, tags)
+		line = truncate(line, cols-4)
+		if i == selected {
+			fmt.Fprintf(&b, "%s▶ %s%s\r\n", ansiReverse, line, ansiReset)
+		} else {
+			fmt.Fprintf(&b, "  %s\r\n", line)
+		}
+	}
+	os.Stdout.WriteString(b.String())
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	if got := fuzzyScore("", "anything"); got != 0 {
+		t.Errorf("empty query scored %d, want 0", got)
+	}
+	for _, q := range []string{"xyz", "vd", "devv"} {
+		if got := fuzzyScore(q, "dev"); got != -1 {
+			t.Errorf("fuzzyScore(%q, \"dev\") = %d, want -1 (no match)", q, got)
+		}
+	}
+	if a, b := fuzzyScore("DEV", "dev-box"), fuzzyScore("dev", "DEV-BOX"); a != b || a < 0 {
+		t.Errorf("matching is not case-insensitive: %d vs %d", a, b)
+	}
+
+	// Each pair lists a better match first.
+	better := []struct{ query, good, worse string }{
+		{"dev", "dev", "dxexv"},        // consecutive characters
+		{"box", "dev box", "dbevobx"},  // start of a word
+		{"gpu", "team gpu", "debugpu"}, // start of a word after a space
+		{"eu", "eu-west", "deque"},     // start of the text
+		{"w1", "us-west1", "usw1"},     // start of a word after a hyphen
+	}
+	for _, tt := range better {
+		good, worse := fuzzyScore(tt.query, tt.good), fuzzyScore(tt.query, tt.worse)
+		if good <= worse {
+			t.Errorf("fuzzyScore(%q): %q scored %d, not above %q with %d", tt.query, tt.good, good, tt.worse, worse)
+		}
+	}
+}
+
+func TestFilterInstances(t *testing.T) {
+	instances := []Instance{
+		{Alias: "staging", Project: "web-dev", Zone: "us-east1-b", Name: "stage"},
+		{Alias: "devbox", Project: "sandbox", Zone: "europe-west1-b", Name: "box"},
+		{Alias: "prod", Project: "web", Zone: "us-central1-a", Name: "prod", Tags: []string{"team-a"}},
+		{Alias: "gpu", Project: "ml", Zone: "us-central1-a", Name: "trainer", Tags: []string{"team-a"}},
+	}
+	aliases := func(list []Instance) []string {
+		var names []string
+		for _, inst := range list {
+			names = append(names, inst.Alias)
+		}
+		return names
+	}
+
+	if got := aliases(filterInstances(instances, "")); !reflect.DeepEqual(got, []string{"staging", "devbox", "prod", "gpu"}) {
+		t.Errorf("empty query reordered instances: %q", got)
+	}
+	// "staging" matches through its project, but an alias prefix wins.
+	if got := aliases(filterInstances(instances, "dev")); !reflect.DeepEqual(got, []string{"devbox", "staging"}) {
+		t.Errorf("filterInstances(\"dev\") = %q", got)
+	}
+	// Tags are searched too; equal scores keep the incoming order.
+	if got := aliases(filterInstances(instances, "team-a")); !reflect.DeepEqual(got, []string{"prod", "gpu"}) {
+		t.Errorf("filterInstances(\"team-a\") = %q", got)
+	}
+	if got := filterInstances(instances, "nothing"); len(got) != 0 {
+		t.Errorf("filterInstances(\"nothing\") = %q, want none", aliases(got))
+	}
+}
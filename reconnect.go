@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// With `connect --reconnect`, a terminal session whose connection drops is
+// opened again: readiness is checked once more, since the instance may have
+// stopped, and attempts back off. Logging out, or a remote command exiting
+// with its own status, ends the loop.
+
+const (
+	reconnectAttempts    = 10               // consecutive reconnects before giving up
+	reconnectDelay       = 2 * time.Second  // wait before the first reconnect, doubled each time
+	reconnectMaxDelay    = 30 * time.Second // upper bound for the wait
+	reconnectStableAfter = time.Minute      // a session this long resets the backoff
+)
+
+// droppedConnection reports whether a terminal session ended because the
+// connection was lost: ssh exits with 255 on connection errors, and with the
+// remote shell's status otherwise.
+func droppedConnection(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == 255
+}
+
+// sessionFailed reports whether gcloud compute ssh failed rather than the
+// session ending normally: the connection was lost, or gcloud reported an
+// error of its own. Any other exit status is the remote shell's, e.g. that
+// of the last command run before logging out.
+func sessionFailed(err error) bool {
+	var exitErr *exec.ExitError
+	if err == nil || droppedConnection(err) || !errors.As(err, &exitErr) {
+		return err != nil
+	}
+	var gerr *gcloudError
+	return errors.As(err, &gerr) && strings.Contains(gerr.Stderr, "ERROR: (gcloud.")
+}
+
+// sessionLoop decides whether a terminal session that just ended is opened
+// again, and paces the reconnects.
+type sessionLoop struct {
+	reconnect bool // --reconnect was given
+	attempt   int
+	delay     time.Duration
+}
+
+// again reports whether to reconnect after a session that lasted length and
+// ended with err, waiting first if needed. Preempted instances are restarted
+// without asking under --reconnect, and after a prompt otherwise.
+func (l *sessionLoop) again(ctx context.Context, inst Instance, err error, length time.Duration) bool {
+	if errors.Is(err, errPreempted) {
+		if !l.reconnect {
+			return offerRestart(ctx, inst)
+		}
+		fmt.Printf("  ℹ Restarting '%s' after the preemption (--reconnect)...\n", inst.Name)
+		return contextError(ctx) == nil
+	}
+	if !l.reconnect || !droppedConnection(err) {
+		return false
+	}
+
+	if length >= reconnectStableAfter || l.delay == 0 {
+		l.attempt, l.delay = 0, reconnectDelay
+	}
+	l.attempt++
+	if l.attempt > reconnectAttempts {
+		fmt.Printf("  ✗ Giving up after %d reconnect attempts.\n", reconnectAttempts)
+		return false
+	}
+	fmt.Printf("  ⚠ Connection to '%s' lost. Reconnecting in %s (attempt %d of %d, Ctrl-C to stop)...\n",
+		inst.Name, l.delay, l.attempt, reconnectAttempts)
+	logger.Info("reconnecting", "name", inst.Name, "attempt", l.attempt, "wait", l.delay)
+	select {
+	case <-ctx.Done():
+		fmt.Println("  ℹ Not reconnecting.")
+		return false
+	case <-time.After(l.delay):
+	}
+	l.delay = min(l.delay*2, reconnectMaxDelay)
+	return true
+}
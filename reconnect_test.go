@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// exitError returns the error of a command that exits with code.
+func exitError(t *testing.T, code int) error {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("needs sh")
+	}
+	err := exec.Command("sh", "-c", fmt.Sprintf("exit %d", code)).Run()
+	if err == nil {
+		t.Fatalf("sh -c 'exit %d' succeeded", code)
+	}
+	return err
+}
+
+func TestDroppedConnection(t *testing.T) {
+	lost, status := exitError(t, 255), exitError(t, 1)
+
+	if !droppedConnection(lost) {
+		t.Error("exit status 255 is not a dropped connection")
+	}
+	if !droppedConnection(newGcloudError("gcloud compute ssh dev", "", lost)) {
+		t.Error("exit status 255 wrapped in a gcloudError is not a dropped connection")
+	}
+	if droppedConnection(status) {
+		t.Error("exit status 1 is a dropped connection")
+	}
+	if droppedConnection(errors.New("exit status 255")) || droppedConnection(nil) {
+		t.Error("an error without an exit status is a dropped connection")
+	}
+}
+
+func TestSessionFailed(t *testing.T) {
+	lost, status := exitError(t, 255), exitError(t, 1)
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"clean logout", nil, false},
+		{"connection lost", lost, true},
+		{"remote status", newGcloudError("gcloud compute ssh dev", "bash: foo: command not found\n", status), false},
+		{"gcloud error", newGcloudError("gcloud compute ssh dev", "ERROR: (gcloud.compute.ssh) Could not fetch resource\n", status), true},
+		{"gcloud did not run", &exec.Error{Name: "gcloud", Err: exec.ErrNotFound}, true},
+	}
+	for _, tt := range tests {
+		if got := sessionFailed(tt.err); got != tt.want {
+			t.Errorf("%s: sessionFailed(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestSessionLoopAgain(t *testing.T) {
+	inst := Instance{Name: "dev"}
+	lost, status := exitError(t, 255), exitError(t, 1)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var loop sessionLoop
+	if loop.again(context.Background(), inst, lost, time.Second) {
+		t.Error("reconnected without --reconnect")
+	}
+
+	loop = sessionLoop{reconnect: true}
+	if loop.again(context.Background(), inst, nil, time.Second) {
+		t.Error("reconnected after a clean logout")
+	}
+	if loop.again(context.Background(), inst, status, time.Second) {
+		t.Error("reconnected after the remote shell exited with its own status")
+	}
+	if loop.again(cancelled, inst, lost, time.Second) {
+		t.Error("reconnected after Ctrl-C")
+	}
+	if loop.attempt != 1 || loop.delay != reconnectDelay {
+		t.Errorf("after one drop attempt = %d, delay = %s; want 1, %s", loop.attempt, loop.delay, reconnectDelay)
+	}
+
+	// The last attempt gives up without waiting.
+	loop = sessionLoop{reconnect: true, attempt: reconnectAttempts, delay: reconnectMaxDelay}
+	if loop.again(context.Background(), inst, lost, time.Second) {
+		t.Error("reconnected after the last attempt")
+	}
+
+	loop = sessionLoop{reconnect: true}
+	if !loop.again(context.Background(), inst, errPreempted, time.Second) {
+		t.Error("did not restart after a preemption under --reconnect")
+	}
+	if loop.again(cancelled, inst, errPreempted, time.Second) {
+		t.Error("restarted after a preemption despite Ctrl-C")
+	}
+}
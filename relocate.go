@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// An instance whose zone has run out of capacity can be moved to another
+// zone listed in its relocate_zones: a machine image of it is taken, a copy
+// is created from the image in the new zone, and only once the copy is
+// running are the original and the image deleted.
+
+// gcloudRelocateTimeout bounds taking the machine image, which copies every
+// disk of the instance.
+const gcloudRelocateTimeout = 30 * time.Minute
+
+// relocationCandidates returns the zones of inst.RelocateZones to try, in
+// order, starting after the current zone and wrapping around.
+func relocationCandidates(inst Instance) []string {
+	zones := inst.RelocateZones
+	for i, z := range zones {
+		if z == inst.Zone {
+			zones = append(append([]string{}, zones[i+1:]...), zones[:i]...)
+			break
+		}
+	}
+	var candidates []string
+	for _, z := range zones {
+		if z != inst.Zone && !contains(candidates, z) {
+			candidates = append(candidates, z)
+		}
+	}
+	return candidates
+}
+
+// relocateInstance offers to move inst out of a zone without capacity and
+// returns it in its new zone. It reports false if the user declined or the
+// move failed, in which case the original instance is left as it was.
+func relocateInstance(ctx context.Context, inst Instance) (Instance, bool) {
+	candidates := relocationCandidates(inst)
+	if len(candidates) == 0 {
+		return inst, false
+	}
+	if !isTerminal(os.Stdin) {
+		fmt.Printf("  ℹ '%s' could be moved to %s (relocate_zones). Run gcp-ssh in a terminal to confirm the move.\n",
+			inst.Name, strings.Join(candidates, ", "))
+		return inst, false
+	}
+	fmt.Printf("  Move '%s' out of %s? It is recreated from a machine image in the first zone with capacity among %s;\n",
+		inst.Name, inst.Zone, strings.Join(candidates, ", "))
+	fmt.Print("  its internal and ephemeral external IPs will change. (y/N): ")
+	if answer := strings.ToLower(readLine(bufio.NewReader(os.Stdin))); answer != "y" && answer != "yes" {
+		fmt.Println("  ℹ Instance left in place.")
+		return inst, false
+	}
+	if contextError(ctx) != nil {
+		return inst, false
+	}
+
+	image := relocationImageName(inst.Name)
+	fmt.Printf("  ℹ Creating machine image '%s' of '%s'...\n", image, inst.Name)
+	imageCtx, cancel := context.WithTimeout(ctx, gcloudRelocateTimeout)
+	defer cancel()
+	if err := runGcloudCommand(imageCtx, "compute", "machine-images", "create", image,
+		"--project", inst.Project,
+		"--source-instance", inst.Name,
+		"--source-instance-zone", inst.Zone); err != nil {
+		fmt.Printf("  ✗ Failed to create machine image: %v\n", err)
+		printGcloudHint(err, inst)
+		return inst, false
+	}
+
+	moved := inst
+	created := false
+	uncertain := "" // zone where a create was cut short
+	for _, zone := range candidates {
+		moved.Zone = zone
+		fmt.Printf("  ℹ Creating '%s' in %s...\n", inst.Name, zone)
+		createCtx, cancel := context.WithTimeout(ctx, gcloudStartTimeout)
+		err := runGcloudCommand(createCtx, "compute", "instances", "create", inst.Name,
+			"--project", inst.Project,
+			"--zone", zone,
+			"--source-machine-image", image)
+		cancel()
+		if err == nil {
+			created = true
+			break
+		}
+		fmt.Printf("  ✗ Failed to create '%s' in %s: %v\n", inst.Name, zone, err)
+		if errors.Is(err, errInterrupted) || errors.Is(err, errTimedOut) {
+			// The create request may already have reached Google Cloud.
+			uncertain = zone
+			break
+		}
+		if !retryableError(err) {
+			printGcloudHint(err, moved)
+			break
+		}
+	}
+	if uncertain != "" {
+		fmt.Printf("  ⚠ A copy of '%s' may still be created in %s; the original is unchanged in %s.\n", inst.Name, uncertain, inst.Zone)
+		fmt.Printf("  ℹ Check with: gcloud compute instances list --project %s --filter=\"name=%s\"\n", inst.Project, inst.Name)
+		fmt.Printf("  ℹ Machine image '%s' was kept. Delete it when done with: gcloud compute machine-images delete %s --project %s\n",
+			image, image, inst.Project)
+		return inst, false
+	}
+	if !created {
+		fmt.Printf("  ✗ '%s' was not moved; it is unchanged in %s.\n", inst.Name, inst.Zone)
+		deleteRelocationImage(inst, image)
+		return inst, false
+	}
+
+	fmt.Printf("  ℹ Deleting the original '%s' in %s...\n", inst.Name, inst.Zone)
+	deleteCtx, cancel := context.WithTimeout(ctx, gcloudStopTimeout)
+	defer cancel()
+	if err := runGcloudCommand(deleteCtx, "compute", "instances", "delete", inst.Name,
+		"--project", inst.Project,
+		"--zone", inst.Zone,
+		"--quiet"); err != nil {
+		fmt.Printf("  ⚠ Could not delete the original in %s: %v. Delete it once you no longer need it.\n", inst.Zone, err)
+	}
+	deleteRelocationImage(inst, image)
+	if !options.dryRun {
+		fmt.Printf("  ✓ '%s' now runs in %s.\n", inst.Name, moved.Zone)
+	}
+	return moved, true
+}
+
+// deleteRelocationImage removes the machine image taken for a move. It runs
+// even after an interrupt so no image is left behind unnoticed.
+func deleteRelocationImage(inst Instance, image string) {
+	ctx, cancel := context.WithTimeout(context.Background(), gcloudStopTimeout)
+	defer cancel()
+	if err := runGcloudCommand(ctx, "compute", "machine-images", "delete", image,
+		"--project", inst.Project,
+		"--quiet"); err != nil {
+		fmt.Printf("  ⚠ Could not delete machine image '%s': %v\n", image, err)
+	}
+}
+
+// relocationImageName names the machine image for a move, within the
+// 63-character resource name limit.
+func relocationImageName(name string) string {
+	suffix := "-relocate-" + time.Now().Format("060102-150405")
+	return strings.TrimRight(name[:min(len(name), 63-len(suffix))], "-") + suffix
+}
+
+// saveRelocatedZone records the new zone of a moved instance under its saved
+// alias, if it has one.
+func saveRelocatedZone(config *Config, configPath string, old Instance, zone string) {
+	for i, saved := range config.Instances {
+		if saved.Alias == old.Alias && saved.Project == old.Project && saved.Name == old.Name && saved.Zone == old.Zone {
+			if dryRunSkip("point alias '%s' to %s", saved.Alias, zone) {
+				return
+			}
+			config.Instances[i].Zone = zone
+			saveConfig(configPath, config)
+			fmt.Printf("  ✓ Alias '%s' now points to %s.\n", saved.Alias, zone)
+			return
+		}
+	}
+}
+
+// capacityError reports whether a readiness failure was the zone running
+// out of resources, the case relocation handles.
+func capacityError(err error) bool {
+	return errors.Is(err, errZoneExhausted) && !errors.Is(err, errInterrupted)
+}
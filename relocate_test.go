@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRelocationCandidates(t *testing.T) {
+	tests := []struct {
+		name  string
+		zone  string
+		zones []string
+		want  []string
+	}{
+		{name: "none configured", zone: "us-central1-a", zones: nil, want: nil},
+		{name: "current zone not listed", zone: "us-central1-a", zones: []string{"us-central1-b", "us-east1-b"}, want: []string{"us-central1-b", "us-east1-b"}},
+		{name: "wraps after current zone", zone: "us-central1-b", zones: []string{"us-central1-a", "us-central1-b", "us-central1-c"}, want: []string{"us-central1-c", "us-central1-a"}},
+		{name: "current zone last", zone: "us-central1-c", zones: []string{"us-central1-a", "us-central1-b", "us-central1-c"}, want: []string{"us-central1-a", "us-central1-b"}},
+		{name: "only current zone", zone: "us-central1-a", zones: []string{"us-central1-a"}, want: nil},
+		{name: "duplicates dropped", zone: "us-central1-a", zones: []string{"us-east1-b", "us-east1-b", "us-central1-a", "us-east1-b"}, want: []string{"us-east1-b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := Instance{Zone: tt.zone, RelocateZones: tt.zones}
+			saved := append([]string(nil), tt.zones...)
+			if got := relocationCandidates(inst); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("relocationCandidates(%s, %q) = %q, want %q", tt.zone, tt.zones, got, tt.want)
+			}
+			if !reflect.DeepEqual(inst.RelocateZones, saved) {
+				t.Errorf("relocationCandidates changed RelocateZones to %q", inst.RelocateZones)
+			}
+		})
+	}
+}
+
+func TestRelocationImageName(t *testing.T) {
+	for _, name := range []string{"dev", strings.Repeat("a", 63), strings.Repeat("a", 45) + "-" + strings.Repeat("b", 17)} {
+		image := relocationImageName(name)
+		if len(image) > 63 {
+			t.Errorf("relocationImageName(%q) = %q, longer than 63 characters", name, image)
+		}
+		if err := validateInstanceName(image); err != nil {
+			t.Errorf("relocationImageName(%q) = %q: %v", name, image, err)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An instance's session setting makes terminal mode attach to a named tmux
+// or screen session on the instance, creating it if needed, so that a
+// dropped connection or a reconnect lands in the same shell.
+
+// defaultSessionName is the remote session used when the setting names none.
+const defaultSessionName = "gcp-ssh"
+
+// sessionNamePattern restricts session names to characters that need no
+// quoting in the remote command. '.' is left out because tmux replaces it
+// with '_', so the session would never be found under its name again.
+var sessionNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// sessionSpec is a parsed session setting.
+type sessionSpec struct {
+	Tool string // "tmux", "screen", or "" for a plain shell
+	Name string
+}
+
+// parseSessionSetting interprets a session setting: "" or "none" for a plain
+// shell, "tmux" or "screen" for the default session, or "tmux:NAME" or
+// "screen:NAME".
+func parseSessionSetting(setting string) (sessionSpec, error) {
+	if setting == "" || setting == "none" {
+		return sessionSpec{}, nil
+	}
+	tool, name, _ := strings.Cut(setting, ":")
+	if tool != "tmux" && tool != "screen" {
+		return sessionSpec{}, fmt.Errorf("invalid session '%s' (use none, tmux, screen, tmux:NAME or screen:NAME)", setting)
+	}
+	if name == "" {
+		name = defaultSessionName
+	}
+	if err := validateSessionName(name); err != nil {
+		return sessionSpec{}, err
+	}
+	return sessionSpec{Tool: tool, Name: name}, nil
+}
+
+func validateSessionName(name string) error {
+	if !sessionNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid session name '%s' (use up to 64 letters, digits, '-' or '_')", name)
+	}
+	return nil
+}
+
+// String returns the setting that parses back to s.
+func (s sessionSpec) String() string {
+	if s.Tool == "" {
+		return "none"
+	}
+	return s.Tool + ":" + s.Name
+}
+
+// remoteCommand attaches to the session, or creates it. Without the tool on
+// the instance it falls back to a login shell.
+func (s sessionSpec) remoteCommand() string {
+	attach := "tmux new-session -A -s " + s.Name
+	if s.Tool == "screen" {
+		attach = "screen -D -R -S " + s.Name
+	}
+	return fmt.Sprintf(`if command -v %s >/dev/null 2>&1; then exec %s; else echo "gcp-ssh: %s is not installed; opening a plain shell." >&2; exec "${SHELL:-/bin/sh}" -l; fi`,
+		s.Tool, attach, s.Tool)
+}
+
+// sessionSSHArgs returns the gcloud compute ssh arguments that attach to the
+// instance's session, if it has one, and says which session it is.
+func sessionSSHArgs(inst Instance) ([]string, error) {
+	spec, err := parseSessionSetting(inst.Session)
+	if err != nil || spec.Tool == "" {
+		return nil, err
+	}
+	fmt.Printf("  ℹ Attaching to %s session '%s'.\n", spec.Tool, spec.Name)
+	return []string{"--ssh-flag=-t", "--command", spec.remoteCommand()}, nil
+}
+
+// ─── Listing ─────────────────────────────────────────────────────────────────
+
+// withAccount adds --account to gcloud args if inst has an account.
+func withAccount(inst Instance, args ...string) []string {
+	if inst.GcloudAccount != "" {
+		args = append(args, "--account", inst.GcloudAccount)
+	}
+	return args
+}
+
+// toolMissingStatus is the exit status of the listing script when the
+// session tool is not installed.
+const toolMissingStatus = 127
+
+// listRemoteSessions lists the tmux or screen sessions on a running instance.
+func listRemoteSessions(ctx context.Context, inst Instance, tool string) ([]sessionRecord, error) {
+	script := `command -v tmux >/dev/null 2>&1 || exit 127; tmux list-sessions -F '#{session_name}|#{session_windows}|#{session_attached}|#{session_created}' 2>/dev/null; exit 0`
+	if tool == "screen" {
+		// screen -ls exits non-zero even when it lists sessions.
+		script = `command -v screen >/dev/null 2>&1 || exit 127; screen -ls; exit 0`
+	}
+	output, err := runGcloudValueCommand(ctx, withAccount(inst, "compute", "ssh", inst.Name,
+		"--project", inst.Project,
+		"--zone", inst.Zone,
+		"--command", script)...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == toolMissingStatus {
+		return nil, fmt.Errorf("%s is not installed on '%s'", tool, inst.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []sessionRecord{}
+	for _, line := range strings.Split(output, "\n") {
+		record := sessionRecord{Alias: inst.Alias, Tool: tool}
+		if tool == "screen" {
+			// e.g. "\t12345.work\t(10/18/26 09:12:01)\t(Detached)"
+			fields := strings.Fields(line)
+			if len(fields) < 2 || !strings.HasPrefix(line, "\t") {
+				continue
+			}
+			_, record.Name, _ = strings.Cut(fields[0], ".")
+			record.Attached = strings.Contains(line, "(Attached)")
+		} else {
+			fields := strings.Split(line, "|")
+			if len(fields) != 4 {
+				continue
+			}
+			record.Name = fields[0]
+			record.Windows, _ = strconv.Atoi(fields[1])
+			attached, _ := strconv.Atoi(fields[2])
+			record.Attached = attached > 0
+			if created, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+				record.Created = timeOrNil(time.Unix(created, 0))
+			}
+		}
+		sessions = append(sessions, record)
+	}
+	return sessions, nil
+}
+
+// sessionsCommand implements `gcp-ssh sessions <alias> [NAME]`: it lists the
+// remote sessions and attaches to the chosen one, or attaches to NAME
+// directly, creating it if needed.
+func sessionsCommand(config *Config, configPath string, args []string) error {
+	fs := flag.NewFlagSet("sessions", flag.ContinueOnError)
+	positional, err := parseFlags(fs, args)
+	if err != nil || len(positional) < 1 || len(positional) > 2 {
+		return errUsage
+	}
+	inst, ok := resolveAlias(config, positional[0])
+	if !ok {
+		return errNotFound
+	}
+	spec, err := parseSessionSetting(inst.Session)
+	if err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		return errFailed
+	}
+	if spec.Tool == "" {
+		spec = sessionSpec{Tool: "tmux", Name: defaultSessionName}
+	}
+	if len(positional) == 2 {
+		if err := validateSessionName(positional[1]); err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			return errUsage
+		}
+		spec.Name = positional[1]
+		return attachRemoteSession(config, configPath, inst, spec)
+	}
+
+	// Like describeInstance, the listing runs as the instance's account
+	// without switching the active one, and prints nothing but the sessions.
+	ctx, stop := interruptContext()
+	defer stop()
+	output, err := runGcloudValueCommand(ctx, withAccount(inst, "compute", "instances", "describe", inst.Name,
+		"--project", inst.Project,
+		"--zone", inst.Zone,
+		"--format="+instanceStateFormat)...)
+	if err != nil {
+		fmt.Printf("  ✗ Failed to read instance status: %v\n", err)
+		printGcloudHint(err, inst)
+		return errStatusFailed
+	}
+	if state := parseInstanceState(output); !strings.EqualFold(state.Status, "RUNNING") {
+		if structuredOutput() {
+			return writeRecords([]sessionRecord{})
+		}
+		fmt.Printf("  ℹ '%s' is %s, so it has no sessions. Start one with: gcp-ssh sessions %s %s\n",
+			inst.Name, state.Status, inst.Alias, spec.Name)
+		return nil
+	}
+
+	sessions, err := listRemoteSessions(ctx, inst, spec.Tool)
+	stop() // the selection below should end on Ctrl-C as usual
+	if err != nil {
+		fmt.Printf("  ✗ Failed to list %s sessions: %v\n", spec.Tool, err)
+		printGcloudHint(err, inst)
+		return errFailed
+	}
+	if structuredOutput() {
+		return writeRecords(sessions)
+	}
+	if len(sessions) == 0 {
+		fmt.Printf("  ℹ No %s sessions on '%s'. Start one with: gcp-ssh sessions %s %s\n", spec.Tool, inst.Name, inst.Alias, spec.Name)
+		return nil
+	}
+
+	fmt.Printf("  %s sessions on '%s':\n", spec.Tool, inst.Name)
+	for i, s := range sessions {
+		var details []string
+		if s.Windows > 0 {
+			details = append(details, fmt.Sprintf("%d window(s)", s.Windows))
+		}
+		if s.Attached {
+			details = append(details, "attached")
+		}
+		if s.Created != nil {
+			details = append(details, "created "+formatAge(time.Since(*s.Created))+" ago")
+		}
+		line := fmt.Sprintf("    %d) %s", i+1, s.Name)
+		if len(details) > 0 {
+			line += "  (" + strings.Join(details, ", ") + ")"
+		}
+		fmt.Println(line)
+	}
+	if !isTerminal(os.Stdin) {
+		return nil
+	}
+	fmt.Printf("  Attach to [1-%d, Enter to cancel]: ", len(sessions))
+	input := readLine(bufio.NewReader(os.Stdin))
+	if input == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(input)
+	if err != nil || n < 1 || n > len(sessions) {
+		fmt.Println("  ✗ Invalid selection.")
+		return errUsage
+	}
+	spec.Name = sessions[n-1].Name
+	if err := validateSessionName(spec.Name); err != nil {
+		fmt.Printf("  ✗ Cannot attach: %v\n", err)
+		return errFailed
+	}
+	return attachRemoteSession(config, configPath, inst, spec)
+}
+
+// attachRemoteSession opens a terminal session attached to spec.
+func attachRemoteSession(config *Config, configPath string, inst Instance, spec sessionSpec) error {
+	inst.ConnectionMode = "terminal"
+	inst.Session = spec.String()
+	return openByMode(config, configPath, inst, connectOptions{})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSessionSetting(t *testing.T) {
+	for setting, want := range map[string]sessionSpec{
+		"":             {},
+		"tmux:":        {Tool: "tmux", Name: defaultSessionName},
+		"none":         {},
+		"tmux":         {Tool: "tmux", Name: defaultSessionName},
+		"screen":       {Tool: "screen", Name: defaultSessionName},
+		"tmux:work":    {Tool: "tmux", Name: "work"},
+		"screen:a_b-1": {Tool: "screen", Name: "a_b-1"},
+	} {
+		got, err := parseSessionSetting(setting)
+		if err != nil || got != want {
+			t.Errorf("parseSessionSetting(%q) = %+v, %v; want %+v", setting, got, err, want)
+			continue
+		}
+		// String gives back a setting that parses to the same session.
+		if again, err := parseSessionSetting(got.String()); err != nil || again != got {
+			t.Errorf("parseSessionSetting(%q) = %+v, %v; want %+v", got.String(), again, err, got)
+		}
+	}
+
+	for _, setting := range []string{"zellij", "Tmux", "tmux:my work", "screen:a;b", "tmux:$(id)", "none:x", "tmux:v1.2"} {
+		if got, err := parseSessionSetting(setting); err == nil {
+			t.Errorf("parseSessionSetting(%q) = %+v, want an error", setting, got)
+		}
+	}
+}
+
+func TestValidateSessionName(t *testing.T) {
+	if err := validateSessionName(strings.Repeat("x", 64)); err != nil {
+		t.Errorf("64-character name rejected: %v", err)
+	}
+	for _, name := range []string{"", strings.Repeat("x", 65), "a b", "a'b", "a/b", "a.b", "ünï"} {
+		if err := validateSessionName(name); err == nil {
+			t.Errorf("validateSessionName(%q) = nil, want an error", name)
+		}
+	}
+}
+
+func TestRemoteCommand(t *testing.T) {
+	tmux := sessionSpec{Tool: "tmux", Name: "work"}.remoteCommand()
+	if !strings.Contains(tmux, "command -v tmux") || !strings.Contains(tmux, "exec tmux new-session -A -s work;") {
+		t.Errorf("tmux remote command = %q", tmux)
+	}
+	screen := sessionSpec{Tool: "screen", Name: "work"}.remoteCommand()
+	if !strings.Contains(screen, "exec screen -D -R -S work;") {
+		t.Errorf("screen remote command = %q", screen)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// instanceShape is a change applied to a stopped instance just before
+// ensureInstanceReady starts it: a machine type from --machine-type, and the
+// machine type and accelerators of a --profile. It lives for one connection
+// and is never saved.
+type instanceShape struct {
+	Profile         string        // profile the shape comes from, if any
+	MachineType     string        // "" keeps the machine type
+	Accelerators    []Accelerator // replace the attached ones if SetAccelerators
+	SetAccelerators bool
+	Restore         bool // undo the change when auto-stop stops the instance
+}
+
+// applyShape reshapes a stopped instance. Only what differs from the
+// instance's current shape is changed. It returns the shape that undoes what
+// was changed, also when a later step failed, or nil if nothing was.
+func applyShape(ctx context.Context, inst Instance, shape *instanceShape) (*instanceShape, error) {
+	if shape == nil || (shape.MachineType == "" && !shape.SetAccelerators) {
+		return nil, nil
+	}
+	details, err := describeInstance(ctx, inst)
+	if err != nil {
+		fmt.Printf("  ✗ Failed to read the instance's shape: %v\n", err)
+		return nil, err
+	}
+	currentType := lastPathSegment(details.MachineType)
+	currentAccs := details.attachedAccelerators()
+	changeType := shape.MachineType != "" && shape.MachineType != currentType
+	changeAccs := shape.SetAccelerators && !sameAccelerators(shape.Accelerators, currentAccs)
+	if !changeType && !changeAccs {
+		fmt.Printf("  ✓ Instance already has the requested shape (%s, %s).\n", currentType, formatAccelerators(currentAccs))
+		return nil, nil
+	}
+
+	undo := &instanceShape{}
+	setType := func() error {
+		if !changeType {
+			return nil
+		}
+		fmt.Printf("  ℹ Changing machine type from %s to %s...\n", currentType, shape.MachineType)
+		if err := setMachineType(ctx, inst, shape.MachineType); err != nil {
+			fmt.Printf("  ✗ Failed to change the machine type: %v\n", err)
+			return err
+		}
+		undo.MachineType = currentType
+		return nil
+	}
+	setAccs := func() error {
+		if !changeAccs {
+			return nil
+		}
+		fmt.Printf("  ℹ Changing accelerators from %s to %s...\n", formatAccelerators(currentAccs), formatAccelerators(shape.Accelerators))
+		if err := setAccelerators(ctx, inst, details, shape.Accelerators); err != nil {
+			fmt.Printf("  ✗ Failed to change the accelerators: %v\n", err)
+			return err
+		}
+		undo.Accelerators, undo.SetAccelerators = currentAccs, true
+		return nil
+	}
+
+	// Detach GPUs before moving to a machine type that cannot take them, and
+	// attach them only once the machine type can.
+	steps := []func() error{setType, setAccs}
+	if len(shape.Accelerators) == 0 {
+		steps = []func() error{setAccs, setType}
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			printGcloudHint(err, inst)
+			return undo, err
+		}
+	}
+	return undo, nil
+}
+
+// pendingRestore returns undo, from applyShape, if --restore asked for the
+// connection's shape to be reversed.
+func pendingRestore(opts connectOptions, undo *instanceShape) *instanceShape {
+	if opts.Shape == nil || !opts.Shape.Restore {
+		return nil
+	}
+	return undo
+}
+
+// restoreShape applies undo, from pendingRestore, once auto-stop has stopped
+// the instance.
+func restoreShape(ctx context.Context, inst Instance, undo *instanceShape) {
+	if undo == nil {
+		return
+	}
+	fmt.Println("  ℹ Restoring the original shape...")
+	if _, err := applyShape(ctx, inst, undo); err != nil {
+		return
+	}
+	if !options.dryRun {
+		fmt.Println("  ✓ Original shape restored.")
+	}
+}
+
+// profileShape returns the shape of one of inst's profiles.
+func profileShape(inst Instance, name string) (*instanceShape, error) {
+	profile, ok := inst.Profiles[name]
+	if !ok {
+		if len(inst.Profiles) == 0 {
+			return nil, fmt.Errorf("'%s' has no profiles; add them under \"profiles\" in the config", inst.Alias)
+		}
+		return nil, fmt.Errorf("'%s' has no profile '%s' (available: %s)", inst.Alias, name, strings.Join(profileNames(inst), ", "))
+	}
+	return &instanceShape{
+		Profile:         name,
+		MachineType:     profile.MachineType,
+		Accelerators:    profile.Accelerators,
+		SetAccelerators: true,
+	}, nil
+}
+
+func setMachineType(ctx context.Context, inst Instance, machineType string) error {
+	ctx, cancel := context.WithTimeout(ctx, gcloudQueryTimeout)
+	defer cancel()
+	return runGcloudCommand(ctx, "compute", "instances", "set-machine-type", inst.Name,
+		"--project", inst.Project,
+		"--zone", inst.Zone,
+		"--machine-type", machineType)
+}
+
+// resizeCommand implements `gcp-ssh resize <alias> <machine-type> [--stop]`.
+func resizeCommand(config *Config, args []string) error {
+	fs := flag.NewFlagSet("resize", flag.ContinueOnError)
+	stop := fs.Bool("stop", false, "stop the instance first if it is running")
+	positional, err := parseFlags(fs, args)
+	if err != nil || len(positional) != 2 {
+		return errUsage
+	}
+	inst, ok := resolveAlias(config, positional[0])
+	if !ok {
+		return errNotFound
+	}
+	machineType := strings.ToLower(positional[1])
+	if err := validateMachineType(machineType); err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		return errUsage
+	}
+
+	ctx, cancelSignals := interruptContext()
+	defer cancelSignals()
+	if !ensureGcloudAccount(ctx, inst.GcloudAccount) {
+		return errAuthFailed
+	}
+	details, err := describeInstance(ctx, inst)
+	if err != nil {
+		fmt.Printf("  ✗ Failed to read instance status: %v\n", err)
+		printGcloudHint(err, inst)
+		return errStatusFailed
+	}
+	if current := lastPathSegment(details.MachineType); current == machineType {
+		fmt.Printf("  ✓ '%s' is already %s.\n", inst.Name, current)
+		return nil
+	}
+	if !strings.EqualFold(details.Status, "TERMINATED") {
+		if !*stop {
+			fmt.Printf("  ✗ '%s' is %s; the machine type can only change while it is stopped. Pass --stop to stop it first.\n",
+				inst.Name, details.Status)
+			return errFailed
+		}
+		if !stopInstance(ctx, inst) {
+			return errFailed
+		}
+	}
+
+	fmt.Printf("  ℹ Changing machine type from %s to %s...\n", lastPathSegment(details.MachineType), machineType)
+	if err := setMachineType(ctx, inst, machineType); err != nil {
+		fmt.Printf("  ✗ Failed to change the machine type: %v\n", err)
+		printGcloudHint(err, inst)
+		return errFailed
+	}
+	if !options.dryRun {
+		fmt.Printf("  ✓ '%s' is now %s. It starts with the new type on the next connect.\n", inst.Name, machineType)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy controls how often ensureInstanceReady retries a start or
+// status check that failed for a transient reason. Empty fields use the
+// defaults below.
+type RetryPolicy struct {
+	Attempts     int    `json:"attempts,omitempty"`      // tries in total, 1 disables retrying
+	InitialDelay string `json:"initial_delay,omitempty"` // wait before the first retry, doubled each time
+	MaxDelay     string `json:"max_delay,omitempty"`     // upper bound for the wait
+}
+
+const (
+	defaultRetryAttempts = 4
+	defaultRetryDelay    = 5 * time.Second
+	defaultRetryMaxDelay = time.Minute
+)
+
+// retrySchedule is a parsed RetryPolicy.
+type retrySchedule struct {
+	attempts int
+	delay    time.Duration
+	maxDelay time.Duration
+}
+
+// parseRetryPolicy fills in the defaults of policy, which may be nil, and
+// checks its values.
+func parseRetryPolicy(policy *RetryPolicy) (retrySchedule, error) {
+	schedule := retrySchedule{attempts: defaultRetryAttempts, delay: defaultRetryDelay, maxDelay: defaultRetryMaxDelay}
+	if policy == nil {
+		return schedule, nil
+	}
+	if policy.Attempts < 0 {
+		return schedule, fmt.Errorf("invalid retry attempts %d (use 1 or more)", policy.Attempts)
+	}
+	if policy.Attempts > 0 {
+		schedule.attempts = policy.Attempts
+	}
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"initial_delay", policy.InitialDelay, &schedule.delay},
+		{"max_delay", policy.MaxDelay, &schedule.maxDelay},
+	} {
+		if field.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(field.value)
+		if err != nil || d <= 0 {
+			return schedule, fmt.Errorf("invalid retry %s '%s' (use a duration like 10s)", field.name, field.value)
+		}
+		*field.dest = d
+	}
+	schedule.maxDelay = max(schedule.maxDelay, schedule.delay)
+	return schedule, nil
+}
+
+// retryableError reports whether a gcloud failure may go away by itself:
+// exhausted zone capacity, server errors and rate limits. Everything else,
+// including unrecognised failures, is treated as fatal.
+func retryableError(err error) bool {
+	return errors.Is(err, errZoneExhausted) || errors.Is(err, errTransient)
+}
+
+// retryGcloud runs step, retrying it with exponential backoff and jitter
+// while it fails with a retryable error. what describes the step in the
+// progress messages, e.g. "Start". Waiting ends early if ctx is cancelled.
+func retryGcloud(ctx context.Context, inst Instance, what string, step func() error) error {
+	schedule, err := parseRetryPolicy(inst.Retry)
+	if err != nil {
+		fmt.Printf("  ⚠ Ignoring retry setting: %v\n", err)
+	}
+
+	delay := schedule.delay
+	for attempt := 1; ; attempt++ {
+		err := step()
+		if err == nil || !retryableError(err) || attempt >= schedule.attempts || options.dryRun {
+			return err
+		}
+		// Wait between half and all of the current delay so that several
+		// clients hitting the same stockout do not retry in lockstep.
+		wait := delay/2 + rand.N(delay/2+1)
+		var gerr *gcloudError
+		errors.As(err, &gerr)
+		fmt.Printf("  ⚠ %s failed (%v). Retrying in %s (attempt %d of %d)...\n",
+			what, gerr.Kind, wait.Round(time.Second), attempt+1, schedule.attempts)
+		logger.Info("retrying", "step", what, "attempt", attempt+1, "of", schedule.attempts, "wait", wait, "err", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w while waiting to retry: %w", contextError(ctx), err)
+		case <-time.After(wait):
+		}
+		delay = min(delay*2, schedule.maxDelay)
+	}
+}
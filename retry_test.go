@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRetryPolicy(t *testing.T) {
+	defaults := retrySchedule{attempts: defaultRetryAttempts, delay: defaultRetryDelay, maxDelay: defaultRetryMaxDelay}
+	if got, err := parseRetryPolicy(nil); err != nil || got != defaults {
+		t.Errorf("parseRetryPolicy(nil) = %+v, %v; want the defaults", got, err)
+	}
+	if got, err := parseRetryPolicy(&RetryPolicy{}); err != nil || got != defaults {
+		t.Errorf("parseRetryPolicy(empty) = %+v, %v; want the defaults", got, err)
+	}
+
+	got, err := parseRetryPolicy(&RetryPolicy{Attempts: 1, InitialDelay: "2s", MaxDelay: "10s"})
+	if want := (retrySchedule{attempts: 1, delay: 2 * time.Second, maxDelay: 10 * time.Second}); err != nil || got != want {
+		t.Errorf("parseRetryPolicy() = %+v, %v; want %+v", got, err, want)
+	}
+	// A max delay below the initial one is raised to it.
+	got, err = parseRetryPolicy(&RetryPolicy{InitialDelay: "2m"})
+	if err != nil || got.maxDelay != 2*time.Minute {
+		t.Errorf("parseRetryPolicy(initial 2m) = %+v, %v; want max delay 2m", got, err)
+	}
+
+	for _, policy := range []RetryPolicy{
+		{Attempts: -1},
+		{InitialDelay: "soon"},
+		{InitialDelay: "0s"},
+		{MaxDelay: "-1m"},
+		{MaxDelay: "10"},
+	} {
+		if _, err := parseRetryPolicy(&policy); err == nil {
+			t.Errorf("parseRetryPolicy(%+v) = nil error, want an error", policy)
+		}
+	}
+}
+
+func TestRetryGcloud(t *testing.T) {
+	fast := Instance{Retry: &RetryPolicy{Attempts: 3, InitialDelay: "1ms", MaxDelay: "2ms"}}
+	transient := &gcloudError{Command: "gcloud compute instances start", Err: errStartFailed, Kind: errTransient}
+	denied := &gcloudError{Command: "gcloud compute instances start", Err: errStartFailed, Kind: errPermissionDenied}
+
+	// failing returns a step that fails with each of errs in turn and then
+	// succeeds, and a pointer to the number of calls made.
+	failing := func(errs ...error) (func() error, *int) {
+		calls := 0
+		return func() error {
+			calls++
+			if calls <= len(errs) {
+				return errs[calls-1]
+			}
+			return nil
+		}, &calls
+	}
+
+	t.Run("succeeds after transient failures", func(t *testing.T) {
+		step, calls := failing(transient, transient)
+		if err := retryGcloud(context.Background(), fast, "Start", step); err != nil || *calls != 3 {
+			t.Errorf("retryGcloud() = %v after %d calls, want nil after 3", err, *calls)
+		}
+	})
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		step, calls := failing(transient, transient, transient, transient)
+		if err := retryGcloud(context.Background(), fast, "Start", step); !errors.Is(err, errTransient) || *calls != 3 {
+			t.Errorf("retryGcloud() = %v after %d calls, want the transient error after 3", err, *calls)
+		}
+	})
+	t.Run("stops on a fatal error", func(t *testing.T) {
+		step, calls := failing(transient, denied)
+		if err := retryGcloud(context.Background(), fast, "Start", step); !errors.Is(err, errPermissionDenied) || *calls != 2 {
+			t.Errorf("retryGcloud() = %v after %d calls, want permission denied after 2", err, *calls)
+		}
+	})
+	t.Run("does not retry in dry-run mode", func(t *testing.T) {
+		saved := options
+		t.Cleanup(func() { options = saved })
+		options.dryRun = true
+		step, calls := failing(transient)
+		if err := retryGcloud(context.Background(), fast, "Start", step); !errors.Is(err, errTransient) || *calls != 1 {
+			t.Errorf("retryGcloud() = %v after %d calls, want one call", err, *calls)
+		}
+	})
+	t.Run("stops waiting when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		slow := Instance{Retry: &RetryPolicy{InitialDelay: "1h"}}
+		step, calls := failing(transient)
+		err := retryGcloud(ctx, slow, "Start", step)
+		if !errors.Is(err, errInterrupted) || !errors.Is(err, errTransient) || *calls != 1 {
+			t.Errorf("retryGcloud() = %v after %d calls, want an interrupted transient error after 1", err, *calls)
+		}
+	})
+}
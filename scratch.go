@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// ScratchSpec describes how to create a throwaway instance for `scratch`
+type ScratchSpec struct {
+	Alias         string `json:"alias"`
+	Project       string `json:"project"`
+	Zone          string `json:"zone"`
+	Template      string `json:"template,omitempty"` // instance template name or URL
+	MachineType   string `json:"machine_type,omitempty"`
+	ImageFamily   string `json:"image_family,omitempty"`
+	ImageProject  string `json:"image_project,omitempty"`
+	GcloudAccount string `json:"gcloud_account,omitempty"`
+}
+
+// ScratchRecord tracks a scratch instance that has been created but not yet
+// deleted, so orphans can be cleaned up later.
+type ScratchRecord struct {
+	Name          string    `json:"name"`
+	Project       string    `json:"project"`
+	Zone          string    `json:"zone"`
+	GcloudAccount string    `json:"gcloud_account,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+const (
+	scratchReadyTimeout = 5 * time.Minute // until the instance is RUNNING
+	scratchSSHTimeout   = 3 * time.Minute // then until sshd accepts connections
+)
+
+// ─── Scratch state ───────────────────────────────────────────────────────────
+
+func getScratchStatePath() string {
+	return getDataPath("scratch.json")
+}
+
+func loadScratchRecords() []ScratchRecord {
+	var records []ScratchRecord
+	data, err := os.ReadFile(getScratchStatePath())
+	if err != nil {
+		return nil
+	}
+	json.Unmarshal(data, &records)
+	return records
+}
+
+func saveScratchRecords(records []ScratchRecord) {
+	if options.dryRun {
+		return
+	}
+	data, _ := json.MarshalIndent(records, "", "  ")
+	os.WriteFile(getScratchStatePath(), data, 0644)
+}
+
+func addScratchRecord(rec ScratchRecord) {
+	saveScratchRecords(append(loadScratchRecords(), rec))
+}
+
+func removeScratchRecord(rec ScratchRecord) {
+	records := loadScratchRecords()
+	for i, r := range records {
+		if r.Name == rec.Name && r.Project == rec.Project && r.Zone == rec.Zone {
+			records = append(records[:i], records[i+1:]...)
+			break
+		}
+	}
+	saveScratchRecords(records)
+}
+
+// ─── Scratch commands ────────────────────────────────────────────────────────
+
+func scratchCommand(config *Config, configPath string, args []string) error {
+	fs := flag.NewFlagSet("scratch", flag.ContinueOnError)
+	machineType := fs.String("machine-type", "", "override the machine type")
+	project := fs.String("project", "", "project to create the instance in")
+	zone := fs.String("zone", "", "zone to create the instance in")
+	account := fs.String("account", "", "gcloud account to use")
+	keep := fs.Bool("keep", false, "keep the instance after the session ends")
+	yes := fs.Bool("yes", false, "delete the instance after the session without asking")
+	positional, err := parseFlags(fs, args)
+	if err != nil || len(positional) != 1 {
+		return errUsage
+	}
+
+	spec := resolveScratchSpec(config, positional[0])
+	if *project != "" {
+		spec.Project = *project
+	}
+	if *zone != "" {
+		spec.Zone = *zone
+	}
+	if *machineType != "" {
+		spec.MachineType = *machineType
+	}
+	if *account != "" {
+		spec.GcloudAccount = *account
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	if !ensureGcloudAccount(ctx, spec.GcloudAccount) {
+		return errAuthFailed
+	}
+	if spec.Project == "" {
+		spec.Project, _ = runGcloudValueCommand(ctx, "config", "get-value", "project")
+	}
+	if spec.Zone == "" {
+		spec.Zone, _ = runGcloudValueCommand(ctx, "config", "get-value", "compute/zone")
+	}
+	if spec.Project == "" || spec.Zone == "" {
+		fmt.Println("  ✗ Project and zone are required. Pass --project/--zone or save a scratch spec.")
+		return errUsage
+	}
+
+	inst, ok := createScratchInstance(ctx, spec)
+	if !ok {
+		if contextError(ctx) == errInterrupted {
+			return errInterrupted
+		}
+		return errFailed
+	}
+	rec := ScratchRecord{Name: inst.Name, Project: inst.Project, Zone: inst.Zone, GcloudAccount: inst.GcloudAccount}
+	if options.dryRun {
+		// Nothing was created, so there is no instance to check or delete.
+		runTerminalSSH(ctx, inst, false)
+		if !*keep {
+			dryRunSkip("offer to delete '%s' after the session", inst.Name)
+		}
+		return nil
+	}
+
+	sessionErr := connectTerminal(ctx, inst)
+	reportInterrupted(sessionErr, inst)
+	stop() // the questions below should end on Ctrl-C as usual
+
+	if *keep {
+		fmt.Printf("  ℹ Keeping scratch instance '%s'. Run 'gcp-ssh scratch-cleanup' to delete it later.\n", inst.Name)
+		return sessionErr
+	}
+	if !*yes {
+		fmt.Printf("  Delete scratch instance '%s'? (Y/n): ", inst.Name)
+		if answer := strings.ToLower(readLine(bufio.NewReader(os.Stdin))); answer == "n" || answer == "no" {
+			fmt.Println("  ℹ Instance kept. Run 'gcp-ssh scratch-cleanup' to delete it later.")
+			return sessionErr
+		}
+	}
+	if !deleteScratchInstance(context.Background(), rec) && sessionErr == nil {
+		return errFailed
+	}
+	return sessionErr
+}
+
+// resolveScratchSpec returns the saved spec with the given alias, or a spec
+// that uses name as an instance template.
+func resolveScratchSpec(config *Config, name string) ScratchSpec {
+	for _, spec := range config.ScratchSpecs {
+		if spec.Alias == name {
+			return spec
+		}
+	}
+	return ScratchSpec{Alias: name, Template: name}
+}
+
+func createScratchInstance(ctx context.Context, spec ScratchSpec) (Instance, bool) {
+	inst := Instance{
+		Alias:          spec.Alias,
+		Project:        spec.Project,
+		Zone:           spec.Zone,
+		Name:           scratchInstanceName(),
+		GcloudAccount:  spec.GcloudAccount,
+		ConnectionMode: "terminal",
+	}
+
+	args := []string{"compute", "instances", "create", inst.Name,
+		"--project", inst.Project,
+		"--zone", inst.Zone}
+	if spec.Template != "" {
+		args = append(args, "--source-instance-template", spec.Template)
+	}
+	if spec.MachineType != "" {
+		args = append(args, "--machine-type", spec.MachineType)
+	}
+	if spec.ImageFamily != "" {
+		args = append(args, "--image-family", spec.ImageFamily)
+	}
+	if spec.ImageProject != "" {
+		args = append(args, "--image-project", spec.ImageProject)
+	}
+
+	rec := ScratchRecord{
+		Name:          inst.Name,
+		Project:       inst.Project,
+		Zone:          inst.Zone,
+		GcloudAccount: inst.GcloudAccount,
+		CreatedAt:     time.Now(),
+	}
+	addScratchRecord(rec)
+
+	fmt.Printf("  ℹ Creating scratch instance '%s' in %s/%s...\n", inst.Name, inst.Project, inst.Zone)
+	createCtx, cancel := context.WithTimeout(ctx, gcloudStartTimeout)
+	defer cancel()
+	if err := runGcloudCommand(createCtx, args...); err != nil {
+		fmt.Printf("  ✗ Failed to create scratch instance: %v\n", err)
+		if errors.Is(err, errInterrupted) {
+			// The create request may already have reached Google Cloud.
+			fmt.Printf("  ℹ '%s' may still be created. Run 'gcp-ssh scratch-cleanup' to delete it.\n", inst.Name)
+			return inst, false
+		}
+		removeScratchRecord(rec)
+		return inst, false
+	}
+	if dryRunSkip("wait for '%s' to be running and accept SSH", inst.Name) {
+		return inst, true
+	}
+
+	if !waitForInstanceStatus(ctx, inst, "RUNNING", scratchReadyTimeout) {
+		fmt.Printf("  ✗ Instance '%s' did not become ready. Run 'gcp-ssh scratch-cleanup' to delete it.\n", inst.Name)
+		return inst, false
+	}
+	fmt.Printf("  ✓ Scratch instance '%s' is running.\n", inst.Name)
+
+	// RUNNING only means the VM has booted far enough to be scheduled; sshd
+	// comes up later, and connecting before that fails with ssh exit 255.
+	fmt.Println("  ℹ Waiting for SSH to become available...")
+	if !waitForSSH(ctx, inst, scratchSSHTimeout) {
+		fmt.Printf("  ✗ SSH on '%s' did not become available. Run 'gcp-ssh scratch-cleanup' to delete it.\n", inst.Name)
+		return inst, false
+	}
+	return inst, true
+}
+
+func deleteScratchInstance(ctx context.Context, rec ScratchRecord) bool {
+	fmt.Printf("  ℹ Deleting scratch instance '%s'...\n", rec.Name)
+	ctx, cancel := context.WithTimeout(ctx, gcloudStopTimeout)
+	defer cancel()
+	if err := runGcloudCommand(ctx, "compute", "instances", "delete", rec.Name,
+		"--project", rec.Project,
+		"--zone", rec.Zone,
+		"--quiet"); err != nil {
+		fmt.Printf("  ✗ Failed to delete '%s': %v. Run 'gcp-ssh scratch-cleanup' to retry.\n", rec.Name, err)
+		return false
+	}
+	removeScratchRecord(rec)
+	fmt.Printf("  ✓ Deleted '%s'.\n", rec.Name)
+	return true
+}
+
+func scratchCleanup(args []string) error {
+	fs := flag.NewFlagSet("scratch-cleanup", flag.ContinueOnError)
+	yes := fs.Bool("yes", false, "delete every orphan without asking")
+	if _, err := parseFlags(fs, args); err != nil {
+		return errUsage
+	}
+
+	records := loadScratchRecords()
+	if len(records) == 0 {
+		fmt.Println("  No orphaned scratch instances.")
+		return nil
+	}
+
+	ctx, stop := interruptContext()
+	defer func() { stop() }()
+	reader := bufio.NewReader(os.Stdin)
+	var err error
+	for _, rec := range records {
+		if contextError(ctx) != nil {
+			fmt.Println("  ✗ Interrupted.")
+			return errInterrupted
+		}
+		fmt.Printf("  • %s (%s/%s, created %s)\n", rec.Name, rec.Project, rec.Zone, rec.CreatedAt.Local().Format(time.DateTime))
+		if !ensureGcloudAccount(ctx, rec.GcloudAccount) {
+			err = errAuthFailed
+			continue
+		}
+		if _, describeErr := runGcloudValueCommand(ctx, "compute", "instances", "describe", rec.Name,
+			"--project", rec.Project,
+			"--zone", rec.Zone,
+			"--format=value(status)"); errors.Is(describeErr, errResourceNotFound) {
+			fmt.Println("    ℹ Instance no longer exists. Forgetting it.")
+			removeScratchRecord(rec)
+			continue
+		} else if describeErr != nil {
+			fmt.Printf("    ✗ Could not check the instance: %v\n", describeErr)
+			err = errFailed
+			continue
+		}
+		if !*yes {
+			stop() // the question should end on Ctrl-C as usual
+			fmt.Print("    Delete it? (y/n): ")
+			answer := strings.ToLower(readLine(reader))
+			ctx, stop = interruptContext()
+			if answer != "y" {
+				continue
+			}
+		}
+		if !deleteScratchInstance(ctx, rec) {
+			err = errFailed
+		}
+	}
+	return err
+}
+
+// scratchInstanceName builds an RFC1035-compliant name such as
+// "scratch-alice-0412-153012".
+func scratchInstanceName() string {
+	owner := "user"
+	if u, err := user.Current(); err == nil {
+		owner = u.Username
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(owner) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	owner = b.String()
+	if owner == "" {
+		owner = "user"
+	}
+	if len(owner) > 20 {
+		owner = owner[:20]
+	}
+	return fmt.Sprintf("scratch-%s-%s", owner, time.Now().Format("0102-150405"))
+}
+
+// waitForSSH polls until sshd on the instance accepts a connection or the
+// timeout expires. Failures other than a refused or dropped connection end
+// the wait at once.
+func waitForSSH(ctx context.Context, inst Instance, timeout time.Duration) bool {
+	if options.dryRun {
+		return true
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := runGcloudValueCommand(ctx, "compute", "ssh", inst.Name,
+			"--project", inst.Project,
+			"--zone", inst.Zone,
+			"--ssh-flag=-o ConnectTimeout=10",
+			"--command", "true")
+		if err == nil {
+			fmt.Println("  ✓ SSH is available.")
+			return true
+		}
+		if !droppedConnection(err) {
+			fmt.Printf("  ✗ SSH check failed: %v\n", err)
+			printGcloudHint(err, inst)
+			return false
+		}
+		logger.Debug("ssh not ready yet", "name", inst.Name, "err", err)
+		if time.Now().After(deadline) {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// waitForInstanceStatus polls the instance until it reports the wanted status
+// or the timeout expires.
+func waitForInstanceStatus(ctx context.Context, inst Instance, want string, timeout time.Duration) bool {
+	if options.dryRun {
+		return true
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := runGcloudValueCommand(ctx, "compute", "instances", "describe", inst.Name,
+			"--project", inst.Project,
+			"--zone", inst.Zone,
+			"--format=value(status)")
+		if err == nil && strings.EqualFold(status, want) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
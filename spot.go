@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Spot and preemptible VMs can be stopped by Google Cloud at any time. When
+// that happens mid-session the SSH connection just goes dead, so terminal
+// sessions on such instances are watched for a preemption and the user is
+// offered to restart the instance and reconnect.
+
+// preemptionPollInterval is how often a terminal session on a Spot VM checks
+// whether the instance is being preempted.
+const preemptionPollInterval = 30 * time.Second
+
+var errPreempted = errors.New("instance was preempted")
+
+// instanceStateFormat reads what ensureInstanceReady needs to know about an
+// instance in one describe call.
+const instanceStateFormat = "value(status,scheduling.provisioningModel,scheduling.preemptible)"
+
+// instanceState is an instance's status and provisioning model.
+type instanceState struct {
+	Status            string
+	ProvisioningModel string // STANDARD or SPOT
+	Preemptible       bool   // legacy preemptible VM
+}
+
+// parseInstanceState parses the output of a describe with
+// instanceStateFormat. Columns are tab-separated and empty when a field is
+// unset, as the provisioning model is on older instances.
+func parseInstanceState(output string) instanceState {
+	fields := strings.Split(strings.TrimRight(output, "\r\n"), "\t")
+	var state instanceState
+	state.Status = strings.TrimSpace(fields[0])
+	if len(fields) > 1 {
+		state.ProvisioningModel = strings.ToUpper(strings.TrimSpace(fields[1]))
+	}
+	if len(fields) > 2 {
+		state.Preemptible = strings.EqualFold(strings.TrimSpace(fields[2]), "true")
+	}
+	return state
+}
+
+// spot reports whether Google Cloud may preempt the instance.
+func (s instanceState) spot() bool {
+	return s.ProvisioningModel == "SPOT" || s.Preemptible
+}
+
+// warnSpot tells the user on connect that the instance may be preempted.
+func warnSpot(inst Instance, state instanceState) {
+	switch {
+	case state.ProvisioningModel == "SPOT":
+		fmt.Printf("  ⚠ '%s' is a Spot VM: Google Cloud may stop it at any time. Terminal sessions are watched for preemption.\n", inst.Name)
+	case state.Preemptible:
+		fmt.Printf("  ⚠ '%s' is a preemptible VM: Google Cloud may stop it at any time, and does after 24 hours. Terminal sessions are watched for preemption.\n", inst.Name)
+	}
+}
+
+// wasPreempted reports whether Google Cloud has preempted inst since the
+// given time, according to the zone's operation log.
+func wasPreempted(ctx context.Context, inst Instance, since time.Time) (bool, error) {
+	filter := fmt.Sprintf("operationType=compute.instances.preempted AND targetLink~/instances/%s$ AND insertTime>=%s",
+		inst.Name, since.UTC().Format(time.RFC3339))
+	output, err := runGcloudValueCommand(ctx, "compute", "operations", "list",
+		"--project", inst.Project,
+		"--zones", inst.Zone,
+		"--filter="+filter,
+		"--format=value(name)")
+	if err != nil {
+		return false, err
+	}
+	return output != "", nil
+}
+
+// preemptionWatch polls for a preemption while a terminal session runs.
+type preemptionWatch struct {
+	inst   Instance
+	since  time.Time
+	seen   atomic.Bool
+	cancel context.CancelFunc
+}
+
+// watchPreemption starts watching inst until stop is called.
+func watchPreemption(inst Instance) *preemptionWatch {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &preemptionWatch{inst: inst, since: time.Now(), cancel: cancel}
+	go w.run(ctx)
+	return w
+}
+
+func (w *preemptionWatch) run(ctx context.Context) {
+	ticker := time.NewTicker(preemptionPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		preempted, err := wasPreempted(ctx, w.inst, w.since)
+		if err != nil {
+			logger.Debug("preemption check failed", "name", w.inst.Name, "err", err)
+			continue
+		}
+		if preempted {
+			w.seen.Store(true)
+			logger.Warn("instance preempted", "name", w.inst.Name)
+			// The terminal may be in raw mode, hence the explicit carriage returns.
+			fmt.Fprintf(os.Stderr, "\r\n  ⚠ Google Cloud is preempting '%s'; this session is about to drop.\r\n", w.inst.Name)
+			return
+		}
+	}
+}
+
+// stop ends the watch and reports whether the instance was preempted. A
+// session that failed is checked once more, as it may have dropped before
+// the next poll.
+func (w *preemptionWatch) stop(sessionErr error) bool {
+	w.cancel()
+	if w.seen.Load() || sessionErr == nil {
+		return w.seen.Load()
+	}
+	preempted, err := wasPreempted(context.Background(), w.inst, w.since)
+	if err != nil {
+		logger.Debug("preemption check failed", "name", w.inst.Name, "err", err)
+	}
+	return preempted
+}
+
+// offerRestart asks whether to restart a preempted instance and reconnect.
+func offerRestart(ctx context.Context, inst Instance) bool {
+	if !isTerminal(os.Stdin) {
+		fmt.Printf("  ℹ Restart it and reconnect with: gcp-ssh connect %s\n", inst.Alias)
+		return false
+	}
+	fmt.Printf("  Restart '%s' and reconnect? (Y/n): ", inst.Name)
+	if answer := strings.ToLower(readLine(bufio.NewReader(os.Stdin))); answer == "n" || answer == "no" {
+		return false
+	}
+	return contextError(ctx) == nil
+}
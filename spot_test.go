@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestParseInstanceState(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   instanceState
+	}{
+		{name: "standard", output: "RUNNING\tSTANDARD\tFalse", want: instanceState{Status: "RUNNING", ProvisioningModel: "STANDARD"}},
+		{name: "spot", output: "TERMINATED\tSPOT\tFalse\n", want: instanceState{Status: "TERMINATED", ProvisioningModel: "SPOT"}},
+		{name: "lowercase model", output: "RUNNING\tspot\t", want: instanceState{Status: "RUNNING", ProvisioningModel: "SPOT"}},
+		{name: "legacy preemptible without model", output: "TERMINATED\t\tTrue", want: instanceState{Status: "TERMINATED", Preemptible: true}},
+		{name: "no scheduling fields", output: "STOPPING\t\t", want: instanceState{Status: "STOPPING"}},
+		{name: "status only", output: "RUNNING", want: instanceState{Status: "RUNNING"}},
+		{name: "empty", output: "", want: instanceState{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseInstanceState(tt.output); got != tt.want {
+				t.Errorf("parseInstanceState(%q) = %+v, want %+v", tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstanceStateSpot(t *testing.T) {
+	tests := []struct {
+		state instanceState
+		want  bool
+	}{
+		{state: instanceState{ProvisioningModel: "STANDARD"}, want: false},
+		{state: instanceState{ProvisioningModel: "SPOT"}, want: true},
+		{state: instanceState{Preemptible: true}, want: true},
+		{state: instanceState{}, want: false},
+	}
+	for _, tt := range tests {
+		if got := tt.state.spot(); got != tt.want {
+			t.Errorf("%+v.spot() = %v, want %v", tt.state, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// statsGroup summarizes the sessions of one alias or project.
+type statsGroup struct {
+	Key                  string
+	Sessions             int
+	Failures             map[string]int
+	TotalSessionSeconds  float64
+	MedianSessionSeconds float64
+	MedianWaitSeconds    float64
+	MaxWaitSeconds       float64
+
+	sessionTimes []float64
+	waitTimes    []float64
+}
+
+// statsReport holds the sessions grouped by alias and by project.
+type statsReport struct {
+	Aliases  []statsGroup
+	Projects []statsGroup
+}
+
+func statsCommand(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	since := fs.String("since", "", "only include sessions newer than this (e.g. 7d, 36h, 2024-05-01)")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if _, err := parseFlags(fs, args); err != nil {
+		return errUsage
+	}
+
+	var cutoff time.Time
+	if *since != "" {
+		var err error
+		if cutoff, err = parseSince(*since); err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			return errUsage
+		}
+	}
+
+	report := buildStats(loadHistory(), cutoff)
+	if *asJSON {
+		options.output = "json"
+	}
+	if structuredOutput() {
+		records := []statsRecord{}
+		for _, groups := range []struct {
+			name string
+			list []statsGroup
+		}{{"alias", report.Aliases}, {"project", report.Projects}} {
+			for _, g := range groups.list {
+				records = append(records, statsRecord{
+					Group:                groups.name,
+					Key:                  g.Key,
+					Sessions:             g.Sessions,
+					Failures:             g.Failures,
+					TotalSessionSeconds:  g.TotalSessionSeconds,
+					MedianSessionSeconds: g.MedianSessionSeconds,
+					MedianWaitSeconds:    g.MedianWaitSeconds,
+					MaxWaitSeconds:       g.MaxWaitSeconds,
+				})
+			}
+		}
+		return writeRecords(records)
+	}
+
+	if len(report.Aliases) == 0 {
+		fmt.Println("  No sessions recorded.")
+		return nil
+	}
+	printStatsTable("Sessions by alias", report.Aliases)
+	printStatsTable("Sessions by project", report.Projects)
+	return nil
+}
+
+func buildStats(history []HistoryEntry, cutoff time.Time) statsReport {
+	aliases := map[string]*statsGroup{}
+	projects := map[string]*statsGroup{}
+	group := func(groups map[string]*statsGroup, key string) *statsGroup {
+		if groups[key] == nil {
+			groups[key] = &statsGroup{Key: key, Failures: map[string]int{}}
+		}
+		return groups[key]
+	}
+
+	for _, entry := range history {
+		if entry.Time.Before(cutoff) {
+			continue
+		}
+		alias := entry.Alias
+		if alias == "" {
+			alias = "(" + entry.Name + ")"
+		}
+		for _, g := range []*statsGroup{group(aliases, alias), group(projects, entry.Project)} {
+			if entry.Outcome != "ok" {
+				g.Failures[entry.ErrorCategory]++
+			} else {
+				g.Sessions++
+				g.waitTimes = append(g.waitTimes, entry.WaitSeconds)
+			}
+			if entry.DurationSeconds > 0 {
+				g.sessionTimes = append(g.sessionTimes, entry.DurationSeconds)
+				g.TotalSessionSeconds += entry.DurationSeconds
+			}
+		}
+	}
+
+	return statsReport{Aliases: finishStats(aliases), Projects: finishStats(projects)}
+}
+
+// finishStats computes medians and returns the groups ordered by number of
+// sessions.
+func finishStats(groups map[string]*statsGroup) []statsGroup {
+	result := make([]statsGroup, 0, len(groups))
+	for _, g := range groups {
+		g.MedianSessionSeconds = median(g.sessionTimes)
+		g.MedianWaitSeconds = median(g.waitTimes)
+		for _, w := range g.waitTimes {
+			g.MaxWaitSeconds = max(g.MaxWaitSeconds, w)
+		}
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Sessions != result[j].Sessions {
+			return result[i].Sessions > result[j].Sessions
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+func printStatsTable(title string, groups []statsGroup) {
+	fmt.Printf("  ┌─ %s:\n", title)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  │  NAME\tSESSIONS\tTOTAL\tMEDIAN\tMEDIAN WAIT\tMAX WAIT\tFAILURES")
+	for _, g := range groups {
+		var failures []string
+		for category, n := range g.Failures {
+			failures = append(failures, fmt.Sprintf("%s=%d", category, n))
+		}
+		sort.Strings(failures)
+		failureText := "-"
+		if len(failures) > 0 {
+			failureText = strings.Join(failures, " ")
+		}
+		fmt.Fprintf(w, "  │  %s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			g.Key, g.Sessions,
+			formatSeconds(g.TotalSessionSeconds), formatSeconds(g.MedianSessionSeconds),
+			formatSeconds(g.MedianWaitSeconds), formatSeconds(g.MaxWaitSeconds),
+			failureText)
+	}
+	w.Flush()
+	fmt.Println("  └─")
+}
+
+// parseSince accepts a relative window ("7d", "2w", "36h") or a date
+// ("2024-05-01") and returns the matching cutoff time.
+func parseSince(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	if n := len(value); n > 1 && (value[n-1] == 'd' || value[n-1] == 'w') {
+		if count, err := strconv.Atoi(value[:n-1]); err == nil && count >= 0 {
+			days := count
+			if value[n-1] == 'w' {
+				days *= 7
+			}
+			return time.Now().AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value '%s' (use e.g. 7d, 2w, 36h or 2024-05-01)", value)
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// formatSeconds renders a number of seconds compactly, e.g. "45s", "3m20s" or
+// "5h10m". Zero renders as "-".
+func formatSeconds(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return formatAge(d)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration // how far back from now, for relative values
+		date    string        // the expected cutoff, for dates
+		wantErr bool
+	}{
+		{value: "7d", want: 7 * 24 * time.Hour},
+		{value: "2w", want: 14 * 24 * time.Hour},
+		{value: "0d"},
+		{value: "36h", want: 36 * time.Hour},
+		{value: "90m", want: 90 * time.Minute},
+		{value: "2024-05-01", date: "2024-05-01"},
+		{value: "", wantErr: true},
+		{value: "d", wantErr: true},
+		{value: "-3d", wantErr: true},
+		{value: "-1h", wantErr: true},
+		{value: "2024-13-01", wantErr: true},
+		{value: "week", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			before := time.Now()
+			got, err := parseSince(tt.value)
+			after := time.Now()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSince(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			switch {
+			case tt.wantErr:
+			case tt.date != "":
+				want, _ := time.ParseInLocation(time.DateOnly, tt.date, time.Local)
+				if !got.Equal(want) {
+					t.Errorf("parseSince(%q) = %v, want %v", tt.value, got, want)
+				}
+			default:
+				// Days are calendar days, which may differ from 24h across a
+				// daylight saving change.
+				earliest := before.Add(-tt.want - time.Hour)
+				latest := after.Add(-tt.want + time.Hour)
+				if got.Before(earliest) || got.After(latest) {
+					t.Errorf("parseSince(%q) = %v, want about %v ago", tt.value, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Raw terminal handling is done through stty so the tool keeps working without
+// third-party dependencies. Windows consoles are treated as non-interactive and
+// fall back to the line-oriented prompts.
+
+// interactiveTerminal reports whether both stdin and stdout are terminals
+// that support raw mode, so full-screen views can be used.
+func interactiveTerminal() bool {
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+// enableRawMode switches stdin to unbuffered, no-echo input. Reads return
+// after at most 100ms even if no key was pressed, so callers can poll for
+// other events. The returned function restores the previous settings.
+func enableRawMode() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "-isig", "min", "0", "time", "1"); err != nil {
+		return nil, err
+	}
+	return func() { stty(saved) }, nil
+}
+
+// terminalSize returns the number of rows and columns of the terminal.
+func terminalSize() (int, int) {
+	rows, cols := 24, 80
+	if size, err := stty("size"); err == nil {
+		var r, c int
+		if n, _ := fmt.Sscanf(size, "%d %d", &r, &c); n == 2 && r > 0 && c > 0 {
+			rows, cols = r, c
+		}
+	}
+	return rows, cols
+}
+
+// Key codes produced by readKey.
+const (
+	keyNone = iota
+	keyRune
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyInterrupt
+	keyClearLine
+)
+
+// readKey waits up to 100ms for a key press in raw mode and decodes it. Escape
+// sequences for arrow keys arrive in a single read. For keyRune the typed
+// (or pasted) text is returned as well.
+func readKey() (int, string) {
+	buf := make([]byte, 16)
+	n, _ := os.Stdin.Read(buf)
+	if n == 0 {
+		return keyNone, ""
+	}
+	b := buf[:n]
+	switch {
+	case b[0] == 27 && n >= 3 && (b[1] == '[' || b[1] == 'O'):
+		switch b[2] {
+		case 'A':
+			return keyUp, ""
+		case 'B':
+			return keyDown, ""
+		case 'C':
+			return keyRight, ""
+		case 'D':
+			return keyLeft, ""
+		}
+		return keyNone, ""
+	case b[0] == 27:
+		return keyEscape, ""
+	case b[0] == '\r' || b[0] == '\n':
+		return keyEnter, ""
+	case b[0] == 127 || b[0] == 8:
+		return keyBackspace, ""
+	case b[0] == 3 || b[0] == 4:
+		return keyInterrupt, ""
+	case b[0] == 14: // Ctrl-N
+		return keyDown, ""
+	case b[0] == 16: // Ctrl-P
+		return keyUp, ""
+	case b[0] == 21: // Ctrl-U
+		return keyClearLine, ""
+	case b[0] < 32:
+		return keyNone, ""
+	}
+	text := strings.Map(func(r rune) rune {
+		if r < 32 {
+			return -1
+		}
+		return r
+	}, string(b))
+	return keyRune, text
+}
+
+// ANSI escape sequences used by the full-screen views.
+const (
+	ansiAltScreen  = "\x1b[?1049h"
+	ansiMainScreen = "\x1b[?1049l"
+	ansiClear      = "\x1b[H\x1b[2J"
+	ansiHideCursor = "\x1b[?25l"
+	ansiShowCursor = "\x1b[?25h"
+	ansiReverse    = "\x1b[7m"
+	ansiDim        = "\x1b[2m"
+	ansiBold       = "\x1b[1m"
+	ansiGreen      = "\x1b[32m"
+	ansiYellow     = "\x1b[33m"
+	ansiRed        = "\x1b[31m"
+	ansiReset      = "\x1b[0m"
+)
+
+// padRight truncates or pads s with spaces to exactly width runes.
+func padRight(s string, width int) string {
+	s = truncate(s, width)
+	if n := len([]rune(s)); n < width {
+		s += strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// truncate shortens s to at most width runes.
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if width <= 0 {
+		return ""
+	}
+	if len(r) <= width {
+		return s
+	}
+	if width == 1 {
+		return "…"
+	}
+	return string(r[:width-1]) + "…"
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tuiRefreshInterval is how often instance details are re-fetched while the
+// full-screen view is open.
+const tuiRefreshInterval = 30 * time.Second
+
+// Actions that leave the full-screen view to run with a normal terminal.
+const (
+	tuiQuit = iota
+	tuiConnect
+	tuiBrowser
+	tuiTerminal
+	tuiStart
+	tuiStop
+	tuiEdit
+	tuiAdd
+	tuiProfile
+)
+
+// tuiState holds what the full-screen view shows between redraws.
+type tuiState struct {
+	config     *Config
+	configPath string
+	instances  []Instance
+	selected   int
+	details    map[string]detailsUpdate
+	lastConn   map[string]time.Time
+	message    string
+	confirming bool // waiting for y/n to delete the selected instance
+}
+
+// runTUI runs the full-screen fleet view. It returns false without doing
+// anything if the terminal could not be switched to raw mode, so the caller
+// can fall back to the line-oriented prompts.
+func runTUI(config *Config, configPath string) bool {
+	state := &tuiState{config: config, configPath: configPath, details: map[string]detailsUpdate{}}
+	reader := bufio.NewReader(os.Stdin)
+	first := true
+	for {
+		action, inst, ok := state.loop()
+		if !ok {
+			return !first
+		}
+		first = false
+		if action == tuiQuit {
+			fmt.Println("  Bye! 👋")
+			return true
+		}
+
+		state.perform(action, inst)
+		fmt.Print("\n  Press Enter to return...")
+		readLine(reader)
+	}
+}
+
+// loop draws the view and handles keys until an action needs the normal
+// terminal.
+func (s *tuiState) loop() (int, Instance, bool) {
+	restore, err := enableRawMode()
+	if err != nil {
+		return tuiQuit, Instance{}, false
+	}
+	defer restore()
+	fmt.Print(ansiAltScreen + ansiHideCursor)
+	defer fmt.Print(ansiShowCursor + ansiMainScreen)
+
+	s.reload()
+	updates := fetchDetails(s.instances)
+	refresh := time.NewTicker(tuiRefreshInterval)
+	defer refresh.Stop()
+	dirty := true
+
+	for {
+		if dirty {
+			s.render()
+			dirty = false
+		}
+
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				updates = nil
+				continue
+			}
+			s.details[update.Key] = update
+			dirty = true
+			continue
+		case <-refresh.C:
+			updates = fetchDetails(s.instances)
+			continue
+		default:
+		}
+
+		key, text := readKey()
+		if key == keyNone {
+			continue
+		}
+		dirty = true
+
+		if s.confirming {
+			s.confirming = false
+			if key == keyRune && strings.EqualFold(text, "y") {
+				s.deleteSelected()
+			} else {
+				s.message = "Delete cancelled."
+			}
+			continue
+		}
+		s.message = ""
+
+		switch key {
+		case keyUp:
+			s.move(-1)
+			continue
+		case keyDown:
+			s.move(1)
+			continue
+		case keyEnter:
+			if inst, ok := s.current(); ok {
+				return tuiConnect, inst, true
+			}
+			continue
+		case keyEscape, keyInterrupt:
+			return tuiQuit, Instance{}, true
+		case keyRune:
+		default:
+			continue
+		}
+
+		inst, hasInst := s.current()
+		switch text {
+		case "k":
+			s.move(-1)
+		case "j":
+			s.move(1)
+		case "q":
+			return tuiQuit, Instance{}, true
+		case "a":
+			return tuiAdd, Instance{}, true
+		case "p":
+			return tuiProfile, Instance{}, true
+		case "r":
+			updates = fetchDetails(s.instances)
+			s.message = "Refreshing..."
+		case "b", "t", "s", "x", "e":
+			if !hasInst {
+				s.message = "No instance selected."
+				continue
+			}
+			action := map[string]int{"b": tuiBrowser, "t": tuiTerminal, "s": tuiStart, "x": tuiStop, "e": tuiEdit}[text]
+			return action, inst, true
+		case "d":
+			if hasInst {
+				s.confirming = true
+				s.message = fmt.Sprintf("Delete '%s'? (y/n)", inst.Alias)
+			}
+		}
+	}
+}
+
+// perform runs an action with the normal terminal restored.
+func (s *tuiState) perform(action int, inst Instance) {
+	fmt.Println()
+	switch action {
+	case tuiConnect:
+		openByMode(s.config, s.configPath, inst, connectOptions{})
+	case tuiBrowser:
+		inst.ConnectionMode = "browser"
+		openByMode(s.config, s.configPath, inst, connectOptions{})
+	case tuiTerminal:
+		inst.ConnectionMode = "terminal"
+		openByMode(s.config, s.configPath, inst, connectOptions{})
+	case tuiStart:
+		ctx, stop := interruptContext()
+		defer stop()
+		_, err := ensureInstanceReady(ctx, inst)
+		reportInterrupted(err, inst)
+	case tuiStop:
+		ctx, stop := interruptContext()
+		defer stop()
+		if ensureGcloudAccount(ctx, inst.GcloudAccount) {
+			stopInstance(ctx, inst)
+		}
+	case tuiEdit:
+		editInstance(s.config, s.configPath, inst.Alias)
+	case tuiAdd:
+		addInstance(s.config, s.configPath)
+	case tuiProfile:
+		setChromeProfile(s.config, s.configPath)
+	}
+}
+
+// reload re-reads the instance list and history, keeping the selection on the
+// same alias where possible.
+func (s *tuiState) reload() {
+	current, _ := s.current()
+	s.instances = sortedInstances(s.config)
+	s.lastConn = loadLastConnected()
+	s.selected = 0
+	for i, inst := range s.instances {
+		if inst.Alias == current.Alias {
+			s.selected = i
+		}
+	}
+}
+
+func (s *tuiState) current() (Instance, bool) {
+	if s.selected < 0 || s.selected >= len(s.instances) {
+		return Instance{}, false
+	}
+	return s.instances[s.selected], true
+}
+
+func (s *tuiState) move(delta int) {
+	s.selected = min(max(s.selected+delta, 0), max(len(s.instances)-1, 0))
+}
+
+func (s *tuiState) deleteSelected() {
+	inst, ok := s.current()
+	if !ok {
+		return
+	}
+	for i, saved := range s.config.Instances {
+		if saved.Alias == inst.Alias {
+			s.config.Instances = append(s.config.Instances[:i], s.config.Instances[i+1:]...)
+			break
+		}
+	}
+	saveConfig(s.configPath, s.config)
+	s.reload()
+	s.message = fmt.Sprintf("Removed '%s'.", inst.Alias)
+}
+
+// ─── Rendering ───────────────────────────────────────────────────────────────
+
+func (s *tuiState) render() {
+	rows, cols := terminalSize()
+	leftWidth := min(max(cols/3, 26), 40)
+	rightWidth := max(cols-leftWidth-3, 10)
+	bodyRows := max(rows-2, 1)
+
+	left := s.listLines(leftWidth, bodyRows)
+	right := s.detailLines(rightWidth)
+
+	var b strings.Builder
+	b.WriteString(ansiClear)
+	title := fmt.Sprintf(" GCP SSH Launcher · %d saved instance(s)", len(s.instances))
+	b.WriteString(ansiReverse + padRight(title, cols) + ansiReset + "\r\n")
+	for i := 0; i < bodyRows-1; i++ {
+		l, r := strings.Repeat(" ", leftWidth), ""
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		fmt.Fprintf(&b, "%s %s│%s %s\r\n", l, ansiDim, ansiReset, r)
+	}
+
+	footer := s.message
+	if footer == "" {
+		footer = "↑/↓ move · Enter connect · b browser · t terminal · s start · x stop · e edit · a add · d delete · p profile · r refresh · q quit"
+	}
+	b.WriteString(ansiDim + truncate(" "+footer, cols) + ansiReset)
+	os.Stdout.WriteString(b.String())
+}
+
+// listLines renders the left pane; every line is exactly width columns wide.
+func (s *tuiState) listLines(width, height int) []string {
+	if len(s.instances) == 0 {
+		return []string{padRight(" No saved instances.", width), padRight(" Press 'a' to add one.", width)}
+	}
+
+	const statusWidth = 11
+	visible := max(height-1, 1)
+	offset := 0
+	if s.selected >= visible {
+		offset = s.selected - visible + 1
+	}
+
+	var lines []string
+	for i := offset; i < len(s.instances) && i < offset+visible; i++ {
+		inst := s.instances[i]
+		status := "…"
+		if update, ok := s.details[sessionKey(inst)]; ok {
+			status = update.status()
+		}
+		alias := padRight(" "+inst.Alias, width-statusWidth)
+		statusText := statusColor(status) + padRight(status, statusWidth) + ansiReset
+		if i == s.selected {
+			lines = append(lines, ansiReverse+alias+ansiReset+statusText)
+		} else {
+			lines = append(lines, alias+statusText)
+		}
+	}
+	return lines
+}
+
+// detailLines renders the right pane for the selected instance.
+func (s *tuiState) detailLines(width int) []string {
+	inst, ok := s.current()
+	if !ok {
+		return nil
+	}
+	update, fetched := s.details[sessionKey(inst)]
+	d := update.Details
+
+	unknown := "…"
+	if fetched {
+		unknown = "-"
+	}
+	orUnknown := func(v string) string {
+		if v == "" {
+			return unknown
+		}
+		return v
+	}
+	orDash := func(v string) string {
+		if v == "" {
+			return "-"
+		}
+		return v
+	}
+
+	lastConn := "never"
+	if t, ok := s.lastConn[sessionKey(inst)]; ok {
+		lastConn = fmt.Sprintf("%s ago (%s)", formatAge(time.Since(t)), t.Local().Format(time.DateTime))
+	}
+	uptime := unknown
+	if d.Status == "RUNNING" && !d.lastStart().IsZero() {
+		uptime = formatAge(time.Since(d.lastStart()))
+	}
+	var labels []string
+	for k, v := range d.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	mode := inst.ConnectionMode
+	if mode == "" {
+		mode = "browser"
+	}
+
+	fields := [][2]string{
+		{"Alias", inst.Alias},
+		{"Project", inst.Project},
+		{"Zone", inst.Zone},
+		{"Instance", inst.Name},
+		{"", ""},
+		{"Status", orUnknown(d.Status)},
+		{"Uptime", uptime},
+		{"Machine type", orUnknown(lastPathSegment(d.MachineType))},
+		{"Internal IP", orUnknown(d.internalIP())},
+		{"External IP", orUnknown(d.externalIP())},
+		{"Labels", orUnknown(strings.Join(labels, ", "))},
+		{"", ""},
+		{"Mode", mode},
+		{"Account", orDash(inst.GcloudAccount)},
+		{"Auth user", fmt.Sprint(inst.AuthUser)},
+		{"Auto-stop", orDash(inst.AutoStop)},
+		{"Tags", orDash(strings.Join(inst.Tags, ", "))},
+		{"Last connected", lastConn},
+	}
+	if fetched && update.Err != nil {
+		fields = append(fields, [2]string{"", ""}, [2]string{"Error", update.Err.Error()})
+	}
+
+	var lines []string
+	for _, f := range fields {
+		if f[0] == "" {
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, truncate(fmt.Sprintf("%-15s %s", f[0], f[1]), width))
+	}
+	return lines
+}
+
+func statusColor(status string) string {
+	switch status {
+	case "RUNNING":
+		return ansiGreen
+	case "TERMINATED", "STOPPED", "SUSPENDED":
+		return ansiRed
+	case "UNKNOWN", "…":
+		return ansiDim
+	default:
+		return ansiYellow
+	}
+}
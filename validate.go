@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// Project IDs: 6-30 lowercase letters, digits or hyphens, starting with a
+	// letter and not ending with a hyphen. Legacy domain-scoped IDs such as
+	// "example.com:my-project" are accepted too.
+	projectIDPattern = regexp.MustCompile(`^([a-z0-9.-]+:)?[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+	// Zones look like us-central1-a or northamerica-northeast1-b.
+	zonePattern = regexp.MustCompile(`^[a-z]+-[a-z]+[0-9]+-[a-z]$`)
+	// Instance names follow RFC 1035: 1-63 characters, lowercase letters,
+	// digits and hyphens, starting with a letter and not ending with a hyphen.
+	instanceNamePattern = regexp.MustCompile(`^[a-z]([-a-z0-9]{0,61}[a-z0-9])?$`)
+	// Machine types look like e2-medium, n2-standard-16 or n2-custom-8-16384.
+	machineTypePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)+$`)
+)
+
+func validateProjectID(project string) error {
+	if !projectIDPattern.MatchString(project) {
+		return fmt.Errorf("invalid project ID '%s' (6-30 lowercase letters, digits or hyphens, starting with a letter)", project)
+	}
+	return nil
+}
+
+func validateZone(zone string) error {
+	if !zonePattern.MatchString(zone) {
+		return fmt.Errorf("invalid zone '%s' (expected something like us-central1-a)", zone)
+	}
+	return nil
+}
+
+func validateInstanceName(name string) error {
+	if !instanceNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid instance name '%s' (1-63 lowercase letters, digits or hyphens, starting with a letter and not ending with a hyphen)", name)
+	}
+	return nil
+}
+
+func validateMachineType(machineType string) error {
+	if !machineTypePattern.MatchString(machineType) {
+		return fmt.Errorf("invalid machine type '%s' (expected something like n2-standard-16)", machineType)
+	}
+	return nil
+}
+
+func validateAuthUser(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid auth user '%s' (expected 0, 1, 2, ...)", value)
+	}
+	return n, nil
+}
+
+// validateAccount accepts an empty account (use the active one) or an email.
+func validateAccount(account string) error {
+	if account != "" && (!strings.Contains(account, "@") || strings.ContainsAny(account, " \t")) {
+		return fmt.Errorf("invalid account '%s' (expected an email address)", account)
+	}
+	return nil
+}
+
+func validateMode(mode string) error {
+	if mode != "" && mode != "browser" && mode != "terminal" {
+		return fmt.Errorf("mode must be 'browser' or 'terminal', not '%s'", mode)
+	}
+	return nil
+}
+
+// validateTarget checks the fields needed to reach an instance; it is shared
+// by saved instances and the quick commands.
+func validateTarget(inst Instance) error {
+	if err := validateProjectID(inst.Project); err != nil {
+		return err
+	}
+	if err := validateZone(inst.Zone); err != nil {
+		return err
+	}
+	if err := validateInstanceName(inst.Name); err != nil {
+		return err
+	}
+	return validateAccount(inst.GcloudAccount)
+}
+
+// ─── Validated prompts ───────────────────────────────────────────────────────
+
+// promptValid asks until validate accepts the input. If input ends, the last
+// value is returned as is and the caller's final validation rejects it.
+func promptValid(reader *bufio.Reader, label string, validate func(string) error) string {
+	for {
+		fmt.Print("  " + label)
+		input, ok := readInput(reader)
+		if !ok {
+			return input
+		}
+		if err := validate(input); err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			continue
+		}
+		return input
+	}
+}
+
+// promptDefaultValid is promptDefault that re-prompts until validate accepts
+// the resulting value.
+func promptDefaultValid(reader *bufio.Reader, label, current string, validate func(string) error) string {
+	for {
+		value := promptDefault(reader, label, current)
+		if err := validate(value); err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			if _, err := reader.Peek(1); err != nil {
+				return value
+			}
+			continue
+		}
+		return value
+	}
+}
+
+// promptZone asks for a zone, completing unique prefixes from the known zone
+// list and listing the candidates for ambiguous ones. An empty input keeps
+// current.
+func promptZone(reader *bufio.Reader, label, current string) string {
+	for {
+		if current != "" {
+			fmt.Printf("  %s [%s]: ", label, current)
+		} else {
+			fmt.Printf("  %s: ", label)
+		}
+		input, ok := readInput(reader)
+		if !ok {
+			return current
+		}
+		if input == "" && current != "" {
+			return current
+		}
+		zone, err := completeZone(input)
+		if err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			continue
+		}
+		if zone != input {
+			fmt.Printf("  ℹ Using zone '%s'.\n", zone)
+		}
+		return zone
+	}
+}
+
+// completeZone returns input if it is a well-formed zone, or the single known
+// zone it is a prefix of.
+func completeZone(input string) (string, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	zones := knownZones()
+	for _, z := range zones {
+		if z == input {
+			return z, nil
+		}
+	}
+	var matches []string
+	if input != "" {
+		for _, z := range zones {
+			if strings.HasPrefix(z, input) {
+				matches = append(matches, z)
+			}
+		}
+	}
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) > 1:
+		const shown = 12
+		if len(matches) > shown {
+			return "", fmt.Errorf("'%s' matches %d zones: %s, ...", input, len(matches), strings.Join(matches[:shown], ", "))
+		}
+		return "", fmt.Errorf("'%s' matches several zones: %s", input, strings.Join(matches, ", "))
+	}
+	// Accept well-formed zones missing from the list; new zones appear over time.
+	if err := validateZone(input); err != nil {
+		return "", err
+	}
+	return input, nil
+}
+
+// ─── Zone list ───────────────────────────────────────────────────────────────
+
+// bundledZones maps regions to their zone suffixes. It is used until
+// `gcp-ssh zones --refresh` caches the live list.
+var bundledZones = map[string]string{
+	"africa-south1":           "abc",
+	"asia-east1":              "abc",
+	"asia-east2":              "abc",
+	"asia-northeast1":         "abc",
+	"asia-northeast2":         "abc",
+	"asia-northeast3":         "abc",
+	"asia-south1":             "abc",
+	"asia-south2":             "abc",
+	"asia-southeast1":         "abc",
+	"asia-southeast2":         "abc",
+	"australia-southeast1":    "abc",
+	"australia-southeast2":    "abc",
+	"europe-central2":         "abc",
+	"europe-north1":           "abc",
+	"europe-southwest1":       "abc",
+	"europe-west1":            "bcd",
+	"europe-west2":            "abc",
+	"europe-west3":            "abc",
+	"europe-west4":            "abc",
+	"europe-west6":            "abc",
+	"europe-west8":            "abc",
+	"europe-west9":            "abc",
+	"europe-west10":           "abc",
+	"europe-west12":           "abc",
+	"me-central1":             "abc",
+	"me-central2":             "abc",
+	"me-west1":                "abc",
+	"northamerica-northeast1": "abc",
+	"northamerica-northeast2": "abc",
+	"northamerica-south1":     "abc",
+	"southamerica-east1":      "abc",
+	"southamerica-west1":      "abc",
+	"us-central1":             "abcf",
+	"us-east1":                "bcd",
+	"us-east4":                "abc",
+	"us-east5":                "abc",
+	"us-south1":               "abc",
+	"us-west1":                "abc",
+	"us-west2":                "abc",
+	"us-west3":                "abc",
+	"us-west4":                "abc",
+}
+
+// zoneCache is the on-disk cache written by `gcp-ssh zones --refresh`.
+type zoneCache struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Zones     []string  `json:"zones"`
+}
+
+func getZoneCachePath() string {
+	return getDataPath("zones.json")
+}
+
+// knownZones returns the cached zone list, or the bundled one if nothing has
+// been cached yet.
+func knownZones() []string {
+	var cache zoneCache
+	if data, err := os.ReadFile(getZoneCachePath()); err == nil {
+		if json.Unmarshal(data, &cache) == nil && len(cache.Zones) > 0 {
+			return cache.Zones
+		}
+	}
+	var zones []string
+	for region, suffixes := range bundledZones {
+		for _, s := range suffixes {
+			zones = append(zones, region+"-"+string(s))
+		}
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+// zonesCommand implements `gcp-ssh zones [--refresh] [--project P]`.
+func zonesCommand(args []string) error {
+	fs := flag.NewFlagSet("zones", flag.ContinueOnError)
+	refresh := fs.Bool("refresh", false, "fetch the zone list from gcloud and cache it")
+	project := fs.String("project", "", "project to list zones with (defaults to the active one)")
+	if _, err := parseFlags(fs, args); err != nil {
+		return errUsage
+	}
+
+	if *refresh {
+		gcloudArgs := []string{"compute", "zones", "list", "--format=value(name)"}
+		if *project != "" {
+			gcloudArgs = append(gcloudArgs, "--project", *project)
+		}
+		output, err := runGcloudValueCommand(context.Background(), gcloudArgs...)
+		if err != nil {
+			fmt.Printf("  ✗ Failed to list zones: %v\n", err)
+			return errFailed
+		}
+		cache := zoneCache{UpdatedAt: time.Now(), Zones: strings.Fields(output)}
+		sort.Strings(cache.Zones)
+		if dryRunSkip("cache %d zones in %s", len(cache.Zones), getZoneCachePath()) {
+			return nil
+		}
+		data, _ := json.MarshalIndent(cache, "", "  ")
+		os.WriteFile(getZoneCachePath(), data, 0644)
+		fmt.Printf("  ✓ Cached %d zones.\n", len(cache.Zones))
+		return nil
+	}
+
+	if !structuredOutput() {
+		for _, z := range knownZones() {
+			fmt.Println(z)
+		}
+		return nil
+	}
+	records := []zoneRecord{}
+	for _, z := range knownZones() {
+		records = append(records, zoneRecord{Zone: z, Region: z[:max(strings.LastIndex(z, "-"), 0)]})
+	}
+	return writeRecords(records)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// checkValidator runs validate over inputs it must accept and inputs it must
+// reject.
+func checkValidator(t *testing.T, name string, validate func(string) error, valid, invalid []string) {
+	t.Helper()
+	for _, s := range valid {
+		if err := validate(s); err != nil {
+			t.Errorf("%s(%q) = %v, want nil", name, s, err)
+		}
+	}
+	for _, s := range invalid {
+		if err := validate(s); err == nil {
+			t.Errorf("%s(%q) = nil, want an error", name, s)
+		}
+	}
+}
+
+func TestValidateProjectID(t *testing.T) {
+	checkValidator(t, "validateProjectID", validateProjectID,
+		[]string{"my-project", "abcdef", "project-123", "example.com:my-project", strings.Repeat("a", 30)},
+		[]string{"", "short", "1project", "My-Project", "project-", "my_project", strings.Repeat("a", 31), "my project"})
+}
+
+func TestValidateZone(t *testing.T) {
+	checkValidator(t, "validateZone", validateZone,
+		[]string{"us-central1-a", "europe-west10-b", "northamerica-northeast1-c"},
+		[]string{"", "us-central1", "us-central-a", "US-CENTRAL1-A", "us-central1-ab", "uscentral1-a"})
+}
+
+func TestValidateInstanceName(t *testing.T) {
+	checkValidator(t, "validateInstanceName", validateInstanceName,
+		[]string{"a", "dev", "dev-box-2", strings.Repeat("a", 63)},
+		[]string{"", "1dev", "dev-", "Dev", "dev_box", "dev.box", strings.Repeat("a", 64)})
+}
+
+func TestValidateMachineType(t *testing.T) {
+	checkValidator(t, "validateMachineType", validateMachineType,
+		[]string{"e2-medium", "n2-standard-16", "n2-custom-8-16384", "a2-highgpu-1g"},
+		[]string{"", "e2", "E2-medium", "e2-", "e2--medium", "-e2-medium", "e2_medium"})
+}
+
+func TestValidateAccountAndMode(t *testing.T) {
+	checkValidator(t, "validateAccount", validateAccount,
+		[]string{"", "me@example.com", "svc@project.iam.gserviceaccount.com"},
+		[]string{"me", "me @example.com", "me@example.com\t"})
+	checkValidator(t, "validateMode", validateMode,
+		[]string{"", "browser", "terminal"},
+		[]string{"Browser", "ssh", " terminal"})
+}
+
+func TestValidateAuthUser(t *testing.T) {
+	if n, err := validateAuthUser("2"); err != nil || n != 2 {
+		t.Errorf("validateAuthUser(\"2\") = %d, %v; want 2, nil", n, err)
+	}
+	for _, s := range []string{"", "-1", "one", "1.5"} {
+		if _, err := validateAuthUser(s); err == nil {
+			t.Errorf("validateAuthUser(%q) = nil error, want an error", s)
+		}
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	good := Instance{Project: "my-project", Zone: "us-central1-a", Name: "dev"}
+	if err := validateTarget(good); err != nil {
+		t.Fatalf("validateTarget(%+v) = %v", good, err)
+	}
+	for field, broken := range map[string]func(*Instance){
+		"project": func(inst *Instance) { inst.Project = "x" },
+		"zone":    func(inst *Instance) { inst.Zone = "central" },
+		"name":    func(inst *Instance) { inst.Name = "Dev" },
+		"account": func(inst *Instance) { inst.GcloudAccount = "nobody" },
+	} {
+		inst := good
+		broken(&inst)
+		if err := validateTarget(inst); err == nil {
+			t.Errorf("validateTarget with a bad %s = nil, want an error", field)
+		}
+	}
+}
+
+func TestCompleteZone(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tests := []struct {
+		input, want string
+		wantErr     bool
+	}{
+		{input: "us-central1-a", want: "us-central1-a"},
+		{input: " US-EAST1-B ", want: "us-east1-b"},
+		{input: "me-west", wantErr: true},             // matches several zones
+		{input: "asia-east9-z", want: "asia-east9-z"}, // well-formed but not in the list
+		{input: "nowhere", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := completeZone(tt.input)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("completeZone(%q) = %q, %v; want %q (error %v)", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}